@@ -13,7 +13,11 @@ import (
 )
 
 func main() {
-	cfg := config.LoadConfiguration()
+	cfg, err := config.LoadConfiguration()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration: "+err.Error())
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,56 @@
+// Command rewrap re-encrypts stored key material with the current KEK.
+//
+// Rotate the KEK by moving the old key to KEK_PREVIOUS / KEK_PREVIOUS_VERSION,
+// configuring the new key as KEK / KEK_VERSION and running this command once.
+// Afterwards the previous KEK can be removed from the configuration.
+package main
+
+import (
+	"context"
+	"fmt"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+	"laba6/pkg/config"
+	"os"
+)
+
+func main() {
+	cfg, err := config.LoadConfiguration()
+	if err != nil {
+		fail("Invalid configuration: " + err.Error())
+	}
+
+	keks, err := cfg.Kek.Keys()
+	if err != nil {
+		fail("Invalid key-encryption key configuration: " + err.Error())
+	}
+	kekService, err := processors.NewKekService(cfg.Kek.Version, keks)
+	if err != nil {
+		fail("Could not initialize key-encryption key: " + err.Error())
+	}
+
+	db, err := config.NewPostgresDB(cfg.Database)
+	if err != nil {
+		fail("Could not connect to database: " + err.Error())
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	pairCount, err := repositories.NewPostgresKeyStorage(db.DB, kekService).RewrapPrivateKeys(ctx)
+	if err != nil {
+		fail("Failed to rewrap private keys: " + err.Error())
+	}
+	fmt.Printf("Rewrapped %d private keys with KEK version %d\n", pairCount, kekService.CurrentVersion())
+
+	secretCount, err := repositories.NewPostgresAesKeyStorage(db.DB, kekService).RewrapKeys(ctx)
+	if err != nil {
+		fail("Failed to rewrap secret keys: " + err.Error())
+	}
+	fmt.Printf("Rewrapped %d secret keys with KEK version %d\n", secretCount, kekService.CurrentVersion())
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
@@ -13,16 +13,14 @@ import (
 
 	"laba6/docs"
 	"laba6/internal/handlers"
+	"laba6/internal/models"
 	"laba6/internal/processors"
 	"laba6/internal/repositories"
 	"laba6/internal/routes"
 	"laba6/pkg/config"
 )
 
-const (
-	RsaKeySize = 2048
-	AesKeySize = 32
-)
+const AesKeySize = 32
 
 type Application struct {
 	config   *config.Configuration
@@ -36,14 +34,51 @@ func NewApplication(ctx context.Context, cnfg *config.Configuration) (*Applicati
 		return nil, fmt.Errorf("could not connect to database: %w", err)
 	}
 
+	keks, err := cnfg.Kek.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("invalid key-encryption key configuration: %w", err)
+	}
+	kekService, err := processors.NewKekService(cnfg.Kek.Version, keks)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize key-encryption key: %w", err)
+	}
+
+	rsaPolicy := processors.RsaKeyPolicy{
+		DefaultBits: cnfg.Rsa.DefaultBits,
+		AllowedBits: cnfg.Rsa.AllowedBits,
+		MinBits:     cnfg.Rsa.MinBits,
+	}
+	if err := rsaPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid RSA key policy: %w", err)
+	}
+
+	if cnfg.Password.Argon2MemoryKiB < 0 || cnfg.Password.Argon2Iterations < 0 ||
+		cnfg.Password.Argon2Parallelism < 0 || cnfg.Password.Argon2Parallelism > 255 {
+		return nil, fmt.Errorf("invalid password hash policy: argon2 parameters out of range")
+	}
+	passwordPolicy := processors.DefaultPasswordHashPolicy()
+	passwordPolicy.Algorithm = models.PasswordAlgorithm(cnfg.Password.Algorithm)
+	passwordPolicy.Argon2.MemoryKiB = uint32(cnfg.Password.Argon2MemoryKiB)
+	passwordPolicy.Argon2.Iterations = uint32(cnfg.Password.Argon2Iterations)
+	passwordPolicy.Argon2.Parallelism = uint8(cnfg.Password.Argon2Parallelism)
+	passwordPolicy.BcryptCost = cnfg.Password.BcryptCost
+	if err := passwordPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid password hash policy: %w", err)
+	}
+
 	engine := gin.Default()
 
 	repos := repositories.NewRepositories(db)
-	keyStorage := repositories.NewPostgresKeyStorage(db.DB)
+	keyStorage := repositories.NewPostgresKeyStorage(db.DB, kekService)
+	aesKeyStorage := repositories.NewPostgresAesKeyStorage(db.DB, kekService)
+	hmacKeyStorage := repositories.NewPostgresHmacKeyStorage(db.DB, kekService)
+	secretKeyStorage := repositories.NewPostgresSecretKeyStorage(db.DB, kekService)
+	certificateStorage := repositories.NewPostgresCertificateStorage(db.DB)
+	caStorage := repositories.NewPostgresCertificateAuthorityStorage(db.DB)
 
-	procs := processors.NewProcessors(repos, RsaKeySize, AesKeySize)
+	procs := processors.NewProcessors(repos, rsaPolicy, passwordPolicy, AesKeySize, cnfg.Employees.RequireIfMatch)
 
-	handler := handlers.NewHandler(procs, keyStorage)
+	handler := handlers.NewHandler(procs, keyStorage, aesKeyStorage, hmacKeyStorage, secretKeyStorage, certificateStorage, caStorage)
 
 	router := routes.NewRouter(engine)
 	router.SetupRoutes(handler)
@@ -2,67 +2,191 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"laba6/internal/models"
 	"laba6/internal/processors"
+	"laba6/internal/repositories"
 	"net/http"
 )
 
 type AesHandler struct {
-	AesService processors.IAesService
+	AesService    processors.IAesService
+	StreamService processors.IAesStreamService
+	KeyStorage    repositories.IAesKeyStorage
 }
 
-func NewAesHandler(service processors.IAesService) *AesHandler {
-	return &AesHandler{AesService: service}
+func NewAesHandler(service processors.IAesService, streamService processors.IAesStreamService, storage repositories.IAesKeyStorage) *AesHandler {
+	return &AesHandler{AesService: service, StreamService: streamService, KeyStorage: storage}
 }
 
-// GenerateKeys handles the request to generate AES keys.
+// GenerateKeys generates a new AES key, stores it in the vault and returns its metadata.
+// The key material itself never leaves the server.
 func (h *AesHandler) GenerateKeys(c *gin.Context) {
 	keys, err := h.AesService.GenerateSecretKey()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate keys: %s", err)})
 		return
 	}
+
+	meta, err := h.KeyStorage.SaveAesKey(keys)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save key to storage", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// ListKeys returns metadata of all stored AES keys.
+func (h *AesHandler) ListKeys(c *gin.Context) {
+	keys, err := h.KeyStorage.ListAesKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
 	c.JSON(http.StatusOK, keys)
 }
 
-// Encrypt handles the request to encrypt data.
+// GetKeyMetadata returns metadata of a single stored AES key.
+func (h *AesHandler) GetKeyMetadata(c *gin.Context) {
+	keyID := c.Param("keyId")
+
+	meta, err := h.KeyStorage.GetAesKeyMetadata(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// DisableKey marks a stored AES key as disabled so it can no longer be used.
+func (h *AesHandler) DisableKey(c *gin.Context) {
+	keyID := c.Param("keyId")
+
+	meta, err := h.KeyStorage.DisableAesKey(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// Encrypt handles the request to encrypt data. The plaintext and associated data are
+// decoded with the request encoding; a raw application/octet-stream body is the
+// plaintext itself, with the other parameters in the query string.
 func (h *AesHandler) Encrypt(c *gin.Context) {
 	var req struct {
-		AesKey    models.AesKey `json:"aesKey"`
-		PlainText string        `json:"plainText"`
+		KeyID          string                 `json:"keyId" form:"keyId"`
+		Mode           models.AesMode         `json:"mode" form:"mode"`
+		PlainText      string                 `json:"plainText" form:"-"`
+		AssociatedData string                 `json:"associatedData" form:"associatedData"`
+		Encoding       models.PayloadEncoding `json:"encoding" form:"encoding"`
+	}
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if req.KeyID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	cipherText, err := h.AesService.Encrypt(req.AesKey, req.PlainText)
+	plaintext, ok := requestPayload(c, raw, "plainText", req.PlainText, req.Encoding)
+	if !ok {
+		return
+	}
+	associatedData, ok := decodePayloadField(c, "associatedData", req.AssociatedData, req.Encoding)
+	if !ok {
+		return
+	}
+
+	aesKey, ok := h.loadActiveKey(c, req.KeyID)
+	if !ok {
+		return
+	}
+
+	cipherText, err := h.AesService.Encrypt(aesKey, req.Mode, plaintext, associatedData)
 	if err != nil {
+		if errors.Is(err, processors.ErrUnsupportedAesMode) || errors.Is(err, processors.ErrAesAssociatedDataUnsupported) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"cipherText": cipherText})
+	respondBinary(c, cipherText, req.Encoding, func(value string) any { return gin.H{"cipherText": value} })
 }
 
-// Decrypt handles the request to decrypt data.
+// Decrypt handles the request to decrypt data. A raw application/octet-stream body
+// is the binary ciphertext; the plaintext is returned in the request encoding, or
+// raw with Accept: application/octet-stream.
 func (h *AesHandler) Decrypt(c *gin.Context) {
 	var req struct {
-		AesKey           models.AesKey `json:"aesKey"`
-		CipherTextBase64 string        `json:"cipherText"`
+		KeyID            string                 `json:"keyId" form:"keyId"`
+		Mode             models.AesMode         `json:"mode" form:"mode"`
+		CipherTextBase64 string                 `json:"cipherText" form:"-"`
+		AssociatedData   string                 `json:"associatedData" form:"associatedData"`
+		Encoding         models.PayloadEncoding `json:"encoding" form:"encoding"`
+	}
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if req.KeyID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
+	if req.CipherTextBase64, ok = requestBinary(c, raw, "cipherText", req.CipherTextBase64, req.Encoding); !ok {
+		return
+	}
+
+	associatedData, ok := decodePayloadField(c, "associatedData", req.AssociatedData, req.Encoding)
+	if !ok {
+		return
+	}
 
-	plainText, err := h.AesService.Decrypt(req.AesKey, req.CipherTextBase64)
+	aesKey, ok := h.loadActiveKey(c, req.KeyID)
+	if !ok {
+		return
+	}
+
+	plaintext, err := h.AesService.Decrypt(aesKey, req.Mode, req.CipherTextBase64, associatedData)
 	if err != nil {
+		if errors.Is(err, processors.ErrAesAuthenticationFailed) || errors.Is(err, processors.ErrUnsupportedAesMode) ||
+			errors.Is(err, processors.ErrAesAssociatedDataUnsupported) || errors.Is(err, processors.ErrInvalidAesCiphertext) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"plainText": plainText})
+	respondPlaintext(c, plaintext, req.Encoding)
+}
+
+// loadActiveKey fetches a key from the vault and writes an error response if it
+// is missing or disabled.
+func (h *AesHandler) loadActiveKey(c *gin.Context, keyID string) (models.AesKey, bool) {
+	aesKey, meta, err := h.KeyStorage.GetAesKey(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return models.AesKey{}, false
+	}
+
+	if err := processors.CheckAesKeyUsage(meta); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return models.AesKey{}, false
+	}
+	return aesKey, true
+}
+
+func (h *AesHandler) storageError(c *gin.Context, keyID string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("AES key %s not found.", keyID)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
 }
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+)
+
+const (
+	// streamIdleTimeout replaces the server read/write timeouts on streaming
+	// endpoints: the deadline moves forward whenever data flows.
+	streamIdleTimeout = 30 * time.Second
+	// streamStatusTrailer is set to "ok" once the whole body was processed.
+	streamStatusTrailer = "X-Stream-Status"
+	maxKeyIDFieldSize   = 128
+)
+
+// EncryptStream encrypts a raw request body or the "file" part of a multipart upload
+// with a stored key (?keyId= or a "keyId" form field before the file) and streams
+// the STREAM ciphertext back.
+func (h *AesHandler) EncryptStream(c *gin.Context) {
+	h.stream(c, h.StreamService.EncryptStream, func(name string) string { return name + ".enc" })
+}
+
+// DecryptStream verifies and decrypts a stream produced by EncryptStream. If a later
+// segment fails to authenticate after output was sent, the connection is cut so the
+// client never sees a complete response.
+func (h *AesHandler) DecryptStream(c *gin.Context) {
+	h.stream(c, h.StreamService.DecryptStream, func(name string) string { return strings.TrimSuffix(name, ".enc") })
+}
+
+func (h *AesHandler) stream(c *gin.Context, transform func(key models.AesKey, dst io.Writer, src io.Reader) error, outputName func(string) string) {
+	controller := http.NewResponseController(c.Writer)
+	// Keep reading the upload while the response is written; HTTP/1.1 servers
+	// otherwise close the request body once the response starts.
+	_ = controller.EnableFullDuplex()
+
+	src, filename, keyID, err := streamInput(c, controller)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if keyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keyId is required as a query parameter or form field."})
+		return
+	}
+
+	aesKey, ok := h.loadActiveKey(c, keyID)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Trailer", streamStatusTrailer)
+	if filename != "" {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": outputName(filename)}))
+	}
+	c.Status(http.StatusOK)
+
+	dst := &streamWriter{writer: c.Writer, controller: controller}
+	err = transform(aesKey, dst, src)
+	if err == nil {
+		c.Header(streamStatusTrailer, "ok")
+		return
+	}
+
+	if dst.written == 0 {
+		// Nothing was sent yet, so the error replaces the stream headers. gin keeps an
+		// existing Content-Type, so it must be removed for the JSON error to be labelled.
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Trailer")
+		streamError(c, err)
+		return
+	}
+
+	// Part of the output is already on the wire: cut the connection so the
+	// response is visibly truncated and never carries the "ok" trailer.
+	closeConnection(c.Writer)
+	c.Abort()
+}
+
+// closeConnection drops the connection under a response that has already started.
+// gin refuses to hijack a written response, so the net/http writer is hijacked directly.
+func closeConnection(writer gin.ResponseWriter) {
+	var rw http.ResponseWriter = writer
+	if unwrapper, ok := rw.(interface{ Unwrap() http.ResponseWriter }); ok {
+		rw = unwrapper.Unwrap()
+	}
+	if conn, _, err := http.NewResponseController(rw).Hijack(); err == nil {
+		conn.Close()
+	}
+}
+
+// streamInput returns the data to process without buffering it: the "file" part of a
+// multipart/form-data body or the raw body otherwise.
+func streamInput(c *gin.Context, controller *http.ResponseController) (io.Reader, string, string, error) {
+	keyID := c.Query("keyId")
+	body := &deadlineReader{reader: c.Request.Body, controller: controller}
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return body, "", keyID, nil
+	}
+
+	c.Request.Body = io.NopCloser(body)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid multipart body: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, "", "", fmt.Errorf("multipart body has no 'file' part")
+		}
+		if err != nil {
+			return nil, "", "", fmt.Errorf("invalid multipart body: %w", err)
+		}
+
+		switch part.FormName() {
+		case "file":
+			return part, part.FileName(), keyID, nil
+		case "keyId":
+			value, err := io.ReadAll(io.LimitReader(part, maxKeyIDFieldSize))
+			if err != nil {
+				return nil, "", "", fmt.Errorf("invalid multipart body: %w", err)
+			}
+			if keyID == "" {
+				keyID = strings.TrimSpace(string(value))
+			}
+		}
+		part.Close()
+	}
+}
+
+func streamError(c *gin.Context, err error) {
+	if errors.Is(err, processors.ErrInvalidStream) || errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stream processing failed: %s", err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Stream processing failed: %s", err)})
+}
+
+// deadlineReader extends the connection read deadline before every read.
+type deadlineReader struct {
+	reader     io.Reader
+	controller *http.ResponseController
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	_ = r.controller.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+	return r.reader.Read(p)
+}
+
+// streamWriter extends the write deadline before every write and counts the output.
+type streamWriter struct {
+	writer     io.Writer
+	controller *http.ResponseController
+	written    int64
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	_ = w.controller.SetWriteDeadline(time.Now().Add(streamIdleTimeout))
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+const maxCaNameLength = 128
+
+type CreateCaRequest struct {
+	Name          string          `json:"name"`
+	CertificateID int             `json:"certificateId"`
+	Policy        models.CaPolicy `json:"policy"`
+}
+
+type RevokeCertificateRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CaHandler runs internal certificate authorities built from stored keys and CA certificates.
+type CaHandler struct {
+	CaService          processors.ICaService
+	KeyStorage         repositories.IKeyStorage
+	CertificateStorage repositories.ICertificateStorage
+	CaStorage          repositories.ICertificateAuthorityStorage
+}
+
+func NewCaHandler(service processors.ICaService, keyStorage repositories.IKeyStorage,
+	certificateStorage repositories.ICertificateStorage, caStorage repositories.ICertificateAuthorityStorage) *CaHandler {
+	return &CaHandler{CaService: service, KeyStorage: keyStorage, CertificateStorage: certificateStorage, CaStorage: caStorage}
+}
+
+// CreateCertificateAuthority designates a stored CA certificate and its key pair as an
+// internal CA. A CA certificate issued by another internal CA becomes an intermediate,
+// whose policy must be a subset of the parent's.
+func (h *CaHandler) CreateCertificateAuthority(c *gin.Context) {
+	var req CreateCaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxCaNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name is required and may be at most %d characters.", maxCaNameLength)})
+		return
+	}
+	if req.Policy.MaxValidityDays == 0 {
+		req.Policy.MaxValidityDays = processors.DefaultCertificateValidityDays
+	}
+	if req.Policy.MaxValidityDays < 1 || req.Policy.MaxValidityDays > processors.MaxCertificateValidityDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("policy.maxValidityDays must be between 1 and %d.", processors.MaxCertificateValidityDays)})
+		return
+	}
+
+	cert, ok := h.loadCertificate(c, req.CertificateID)
+	if !ok {
+		return
+	}
+	if cert.KeyPairID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Certificate %d does not belong to a stored key pair.", cert.ID)})
+		return
+	}
+	if cert.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Certificate %d is revoked.", cert.ID)})
+		return
+	}
+
+	pair, ok := loadKeyPair(c, h.KeyStorage, *cert.KeyPairID, models.KeyOperationSign, certificateKeyAlgorithms...)
+	if !ok {
+		return
+	}
+	if err := h.CaService.CheckCaCertificate(cert.CertificatePEM, pair.PublicKey); err != nil {
+		certificateError(c, "Certificate cannot act as a CA", err)
+		return
+	}
+	if cert.IssuerCaID != nil {
+		parent, err := h.CaStorage.GetCertificateAuthority(*cert.IssuerCaID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+			return
+		}
+		if err := h.CaService.CheckSubordinatePolicy(parent.Policy, req.Policy); err != nil {
+			certificateError(c, "Invalid subordinate CA policy", err)
+			return
+		}
+	}
+
+	ca, err := h.CaStorage.SaveCertificateAuthority(models.CertificateAuthority{
+		Name:          req.Name,
+		KeyPairID:     pair.ID,
+		CertificateID: cert.ID,
+		ParentID:      cert.IssuerCaID,
+		Policy:        req.Policy,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrCertificateAuthorityExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save certificate authority to storage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ca)
+}
+
+// ListCertificateAuthorities returns all internal CAs.
+func (h *CaHandler) ListCertificateAuthorities(c *gin.Context) {
+	authorities, err := h.CaStorage.ListCertificateAuthorities()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, authorities)
+}
+
+// GetCertificateAuthority returns an internal CA with its certificate.
+func (h *CaHandler) GetCertificateAuthority(c *gin.Context) {
+	ca, ok := h.loadCertificateAuthority(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, ca)
+}
+
+// SignCSR issues a certificate for a CSR under the CA policy and records it in the registry.
+func (h *CaHandler) SignCSR(c *gin.Context) {
+	ca, ok := h.loadCertificateAuthority(c)
+	if !ok {
+		return
+	}
+
+	var req models.CsrSigningRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.CSR == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload. 'csr' is required."})
+		return
+	}
+
+	caCert, ok := h.loadCertificate(c, ca.CertificateID)
+	if !ok {
+		return
+	}
+	if caCert.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The certificate of CA %d is revoked.", ca.ID)})
+		return
+	}
+	caKey, ok := loadKeyPair(c, h.KeyStorage, ca.KeyPairID, models.KeyOperationSign, certificateKeyAlgorithms...)
+	if !ok {
+		return
+	}
+
+	var subjectKey models.KeyPair
+	if req.KeyPairID != nil {
+		if subjectKey, ok = loadKeyPair(c, h.KeyStorage, *req.KeyPairID, models.KeyOperationVerify); !ok {
+			return
+		}
+	}
+
+	cert, err := h.CaService.SignCSR(ca, caKey.PrivateKey, req)
+	if err != nil {
+		certificateError(c, "CSR signing failed", err)
+		return
+	}
+	if req.KeyPairID != nil {
+		if err := h.CaService.CheckCertificateKey(cert.CertificatePEM, subjectKey.PublicKey); err != nil {
+			certificateError(c, "CSR signing failed", err)
+			return
+		}
+	}
+
+	cert.KeyPairID = req.KeyPairID
+	cert.IssuerCaID = &ca.ID
+	saved, err := h.CertificateStorage.SaveCertificate(cert)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save certificate to storage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// ListIssuedCertificates returns the certificates a CA has issued; ?revoked=true lists only revoked ones.
+func (h *CaHandler) ListIssuedCertificates(c *gin.Context) {
+	ca, ok := h.loadCertificateAuthority(c)
+	if !ok {
+		return
+	}
+
+	certs, err := h.CertificateStorage.ListIssuedCertificates(ca.ID, c.Query("revoked") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, certs)
+}
+
+// RevokeCertificate revokes a certificate issued by an internal CA and publishes a new CRL listing it.
+func (h *CaHandler) RevokeCertificate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	var req RevokeCertificateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "unspecified"
+	}
+	reason, ok := processors.RevocationReasons[req.Reason]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown revocation reason %q.", req.Reason)})
+		return
+	}
+
+	cert, err := h.CertificateStorage.RevokeCertificate(id, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Certificate with ID %d not found.", id)})
+		case errors.Is(err, repositories.ErrInvalidCertificateState):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		}
+		return
+	}
+
+	if cert.IssuerCaID != nil {
+		// The revocation is stored either way; if publishing fails, GetCRL reissues
+		// the CRL because it does not list this certificate.
+		if err := h.republishCRL(*cert.IssuerCaID); err != nil {
+			_ = c.Error(fmt.Errorf("failed to publish CRL of CA %d: %w", *cert.IssuerCaID, err))
+		}
+	}
+
+	c.JSON(http.StatusOK, cert)
+}
+
+// GetCRL serves the published CRL of the CA as DER (application/pkix-crl), or as
+// PEM with ?format=pem. A new CRL is only signed when none was published yet, when
+// a revocation is missing from it, or shortly before its next update, so fetching
+// it does not consume CRL numbers.
+func (h *CaHandler) GetCRL(c *gin.Context) {
+	ca, ok := h.loadCertificateAuthority(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", string(models.KeyFormatDER))
+	if format != string(models.KeyFormatDER) && format != string(models.KeyFormatPEM) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be 'der' or 'pem'."})
+		return
+	}
+
+	crl, err := h.CaStorage.GetCrl(ca.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+	revoked, err := h.CertificateStorage.ListIssuedCertificates(ca.ID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	if !h.CaService.CrlIsCurrent(crl, revoked, time.Now()) {
+		caKey, ok := loadKeyPair(c, h.KeyStorage, ca.KeyPairID, models.KeyOperationSign, certificateKeyAlgorithms...)
+		if !ok {
+			return
+		}
+		if crl, err = h.publishCRL(ca, caKey.PrivateKey, revoked); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("CRL generation failed: %s", err)})
+			return
+		}
+	}
+
+	if format == string(models.KeyFormatPEM) {
+		c.Data(http.StatusOK, "application/x-pem-file", pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}))
+		return
+	}
+	c.Data(http.StatusOK, "application/pkix-crl", crl)
+}
+
+// publishCRL signs a CRL with the next CRL number and stores it as the published CRL.
+func (h *CaHandler) publishCRL(ca models.CertificateAuthority, caPrivateKeyPEM string, revoked []models.Certificate) ([]byte, error) {
+	number, err := h.CaStorage.NextCrlNumber(ca.ID)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := h.CaService.CreateCRL(ca, caPrivateKeyPEM, revoked, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.CaStorage.SaveCrl(ca.ID, number, crl); err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
+
+// republishCRL issues a new CRL after a revocation of a certificate of the CA.
+func (h *CaHandler) republishCRL(caID int) error {
+	ca, err := h.CaStorage.GetCertificateAuthority(caID)
+	if err != nil {
+		return err
+	}
+	caKey, err := h.KeyStorage.GetKeyPair(ca.KeyPairID)
+	if err != nil {
+		return err
+	}
+	if err := processors.CheckKeyUsage(caKey, models.KeyOperationSign, time.Now()); err != nil {
+		return err
+	}
+	revoked, err := h.CertificateStorage.ListIssuedCertificates(ca.ID, true)
+	if err != nil {
+		return err
+	}
+	_, err = h.publishCRL(ca, caKey.PrivateKey, revoked)
+	return err
+}
+
+func (h *CaHandler) loadCertificateAuthority(c *gin.Context) (models.CertificateAuthority, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return models.CertificateAuthority{}, false
+	}
+
+	ca, err := h.CaStorage.GetCertificateAuthority(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Certificate authority with ID %d not found.", id)})
+			return models.CertificateAuthority{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return models.CertificateAuthority{}, false
+	}
+	return ca, true
+}
+
+func (h *CaHandler) loadCertificate(c *gin.Context, id int) (models.Certificate, bool) {
+	cert, err := h.CertificateStorage.GetCertificate(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Certificate with ID %d not found.", id)})
+			return models.Certificate{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return models.Certificate{}, false
+	}
+	return cert, true
+}
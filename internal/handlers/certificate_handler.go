@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+// certificateKeyAlgorithms lists the key algorithms that can sign CSRs and certificates.
+var certificateKeyAlgorithms = append([]models.KeyAlgorithm{models.KeyAlgorithmRSA}, ecSignatureAlgorithms...)
+
+type CertificateHandler struct {
+	CertificateService processors.ICertificateService
+	KeyStorage         repositories.IKeyStorage
+	CertificateStorage repositories.ICertificateStorage
+}
+
+func NewCertificateHandler(service processors.ICertificateService, keyStorage repositories.IKeyStorage, certificateStorage repositories.ICertificateStorage) *CertificateHandler {
+	return &CertificateHandler{CertificateService: service, KeyStorage: keyStorage, CertificateStorage: certificateStorage}
+}
+
+// CreateCSR builds a PKCS#10 CSR for a stored key pair, to be signed by an external CA.
+func (h *CertificateHandler) CreateCSR(c *gin.Context) {
+	pair, template, ok := h.loadSigningKey(c)
+	if !ok {
+		return
+	}
+
+	csr, err := h.CertificateService.CreateCSR(pair.PrivateKey, template)
+	if err != nil {
+		certificateError(c, "CSR generation failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keyPairId": pair.ID, "csr": csr})
+}
+
+// SelfSign issues a self-signed certificate for a stored key pair and stores it.
+func (h *CertificateHandler) SelfSign(c *gin.Context) {
+	pair, template, ok := h.loadSigningKey(c)
+	if !ok {
+		return
+	}
+
+	cert, err := h.CertificateService.SelfSign(pair.PrivateKey, template)
+	if err != nil {
+		certificateError(c, "Certificate generation failed", err)
+		return
+	}
+	if pair.ExpiresAt != nil && cert.NotAfter.After(*pair.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The certificate would outlive key pair %d, which expires at %s.", pair.ID, pair.ExpiresAt)})
+		return
+	}
+
+	cert.KeyPairID = &pair.ID
+	saved, err := h.CertificateStorage.SaveCertificate(cert)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save certificate to storage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// ListCertificates returns the certificates issued for a stored key pair.
+func (h *CertificateHandler) ListCertificates(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	if _, err := h.KeyStorage.GetPublicKey(id); err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	certs, err := h.CertificateStorage.ListCertificates(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, certs)
+}
+
+// GetCertificate returns a stored certificate by its ID.
+func (h *CertificateHandler) GetCertificate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	cert, err := h.CertificateStorage.GetCertificate(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Certificate with ID %d not found.", id)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, cert)
+}
+
+// loadSigningKey binds the certificate template and loads the :id key pair for signing.
+func (h *CertificateHandler) loadSigningKey(c *gin.Context) (models.KeyPair, models.CertificateTemplate, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return models.KeyPair{}, models.CertificateTemplate{}, false
+	}
+
+	var template models.CertificateTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return models.KeyPair{}, models.CertificateTemplate{}, false
+	}
+
+	pair, ok := loadKeyPair(c, h.KeyStorage, id, models.KeyOperationSign, certificateKeyAlgorithms...)
+	if !ok {
+		return models.KeyPair{}, models.CertificateTemplate{}, false
+	}
+	return pair, template, true
+}
+
+func certificateError(c *gin.Context, message string, err error) {
+	if errors.Is(err, processors.ErrInvalidCertificateRequest) || errors.Is(err, processors.ErrCaPolicyViolation) ||
+		errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+}
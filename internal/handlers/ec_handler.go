@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+var ecSignatureAlgorithms = []models.KeyAlgorithm{
+	models.KeyAlgorithmECDSAP256, models.KeyAlgorithmECDSAP384, models.KeyAlgorithmEd25519,
+}
+
+type EcHandler struct {
+	EcService  processors.IEcService
+	KeyStorage repositories.IKeyStorage
+}
+
+func NewEcHandler(service processors.IEcService, storage repositories.IKeyStorage) *EcHandler {
+	return &EcHandler{EcService: service, KeyStorage: storage}
+}
+
+type GenerateEcKeysRequest struct {
+	Algorithm models.KeyAlgorithm `json:"algorithm"`
+}
+
+// GenerateEcKeys generates and stores an ECDSA P-256/P-384 or Ed25519 key pair.
+func (h *EcHandler) GenerateEcKeys(c *gin.Context) {
+	var req GenerateEcKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	pair, err := h.EcService.GenerateKeyPair(req.Algorithm)
+	if err != nil {
+		if errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate EC keys", "details": err.Error()})
+		return
+	}
+
+	id, err := h.KeyStorage.SaveKeyPair(pair)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save keys to storage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "algorithm": pair.Algorithm})
+}
+
+type EcSignRequest struct {
+	KeyID      *int                   `json:"keyId" form:"keyId"`
+	PrivateKey string                 `json:"privateKey" form:"-"`
+	Message    string                 `json:"message" form:"-"`
+	Hash       models.HashAlgorithm   `json:"hash" form:"hash"`
+	Encoding   models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// Sign signs a message with either a supplied PKCS#8 PEM private key or a stored key pair.
+// The message is decoded with the request encoding, or is the raw application/octet-stream body.
+func (h *EcHandler) Sign(c *gin.Context) {
+	var req EcSignRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PrivateKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or privateKey must be provided"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+
+	privateKey := req.PrivateKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationSign, ecSignatureAlgorithms...)
+		if !ok {
+			return
+		}
+		privateKey = pair.PrivateKey
+	}
+
+	signature, err := h.EcService.Sign(privateKey, message, req.Hash)
+	if err != nil {
+		signatureError(c, "Signing failed", err)
+		return
+	}
+
+	respondBinary(c, signature, req.Encoding, func(value string) any { return SignResponse{Signature: value} })
+}
+
+type EcVerifyRequest struct {
+	KeyID     *int                   `json:"keyId" form:"keyId"`
+	PublicKey string                 `json:"publicKey" form:"publicKey"`
+	Message   string                 `json:"message" form:"-"`
+	Signature string                 `json:"signature" form:"signature"`
+	Hash      models.HashAlgorithm   `json:"hash" form:"hash"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// Verify checks a signature with either a supplied PKIX PEM public key or a stored key pair.
+// For a raw application/octet-stream message the signature is a query parameter.
+func (h *EcHandler) Verify(c *gin.Context) {
+	var req EcVerifyRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PublicKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or publicKey must be provided"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+	signature, ok := decodeBinaryField(c, "signature", req.Signature, req.Encoding)
+	if !ok {
+		return
+	}
+
+	publicKey := req.PublicKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationVerify, ecSignatureAlgorithms...)
+		if !ok {
+			return
+		}
+		publicKey = pair.PublicKey
+	}
+
+	valid, err := h.EcService.Verify(publicKey, message, signature, req.Hash)
+	if err != nil {
+		signatureError(c, "Verification failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyResponse{Valid: valid})
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"laba6/internal/models"
+	"laba6/internal/processors"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 
@@ -10,21 +13,78 @@ import (
 )
 
 // GetEmployees
-// @Summary      Get all employees
-// @Description  Returns list of all employees from database
+// @Summary      List employees
+// @Description  Returns a page of employees. Filters are combined with AND; repeat department or position to match any of several values.
+// @Description  Pages are selected either by offset or by the opaque next_cursor of the previous page, which stays stable while rows are inserted.
 // @Tags         employees
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Employee
+// @Param        department      query     []string  false  "Department (repeatable)"  collectionFormat(multi)
+// @Param        position        query     []string  false  "Position (repeatable)"  collectionFormat(multi)
+// @Param        salary_min      query     number    false  "Minimum salary (inclusive)"
+// @Param        salary_max      query     number    false  "Maximum salary (inclusive)"
+// @Param        created_after   query     string    false  "Created at or after (RFC 3339)"
+// @Param        created_before  query     string    false  "Created before (RFC 3339)"
+// @Param        updated_after   query     string    false  "Updated at or after (RFC 3339)"
+// @Param        updated_before  query     string    false  "Updated before (RFC 3339)"
+// @Param        sort            query     string    false  "Comma separated sort fields, '-' prefix for descending, e.g. department,-salary"
+// @Param        limit           query     int       false  "Page size (default 20, max 100)"
+// @Param        offset          query     int       false  "Rows to skip; cannot be combined with cursor"
+// @Param        cursor          query     string    false  "next_cursor of the previous page"
+// @Success      200  {object}  models.EmployeePage
+// @Failure      400  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /v1/employees [get]
 func (h *Handler) GetEmployees(c *gin.Context) {
-	employees, err := h.processors.EmployeeProcessor.GetAllEmployees()
+	var query models.EmployeeListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	page, err := h.processors.EmployeeProcessor.ListEmployees(query)
 	if err != nil {
+		if errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get employees"})
 		return
 	}
-	c.JSON(http.StatusOK, employees)
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchEmployees
+// @Summary      Search employees
+// @Description  Full-text search over name, position and department. Every word must match the start of a word, so "sen eng" finds "Senior Engineer".
+// @Description  Results are ordered by relevance, name matches first, and carry the fields with matched words wrapped in <mark></mark>; the rest of the text is HTML escaped.
+// @Tags         employees
+// @Accept       json
+// @Produce      json
+// @Param        q       query     string  true   "Search text"
+// @Param        limit   query     int     false  "Page size (default 20, max 100)"
+// @Param        offset  query     int     false  "Results to skip"
+// @Success      200  {object}  models.EmployeeSearchPage
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/employees/search [get]
+func (h *Handler) SearchEmployees(c *gin.Context) {
+	var query models.EmployeeSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	page, err := h.processors.EmployeeProcessor.SearchEmployees(query)
+	if err != nil {
+		if errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search employees"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // GetEmployee
@@ -35,6 +95,7 @@ func (h *Handler) GetEmployees(c *gin.Context) {
 // @Produce      json
 // @Param        id   path      int  true  "Employee ID"
 // @Success      200  {object}  models.Employee
+// @Header       200  {string}  ETag  "Current version, to send as If-Match on writes"
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /v1/employees/{id} [get]
@@ -51,17 +112,20 @@ func (h *Handler) GetEmployee(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
 		return
 	}
+	c.Header("ETag", versionETag(employee.Version))
 	c.JSON(http.StatusOK, employee)
 }
 
 // CreateEmployee
 // @Summary      Create employee
-// @Description  Adds a new employee
+// @Description  Adds a new employee and returns it as stored, with its id, timestamps and version
 // @Tags         employees
 // @Accept       json
 // @Produce      json
 // @Param        employee  body      models.Employee  true  "Employee"
 // @Success      201  {object}  models.Employee
+// @Header       201  {string}  Location  "URL of the created employee"
+// @Header       201  {string}  ETag      "Version of the created employee"
 // @Failure      400  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /v1/employees [post]
@@ -72,7 +136,7 @@ func (h *Handler) CreateEmployee(c *gin.Context) {
 		return
 	}
 
-	err := h.processors.EmployeeProcessor.CreateEmployee(
+	created, err := h.processors.EmployeeProcessor.CreateEmployee(
 		employee.Name,
 		employee.Position,
 		employee.Department,
@@ -97,19 +161,24 @@ func (h *Handler) CreateEmployee(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, employee)
+	c.Header("Location", path.Join(c.Request.URL.Path, strconv.Itoa(created.ID)))
+	c.Header("ETag", versionETag(created.Version))
+	c.JSON(http.StatusCreated, created)
 }
 
 // DeleteEmployee
 // @Summary      Delete employee
-// @Description  Deletes employee by ID
+// @Description  Deletes employee by ID. With If-Match the employee is only deleted in the expected version.
 // @Tags         employees
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Employee ID"
+// @Param        id        path      int     true   "Employee ID"
+// @Param        If-Match  header    string  false  "ETag of the version to delete; required in strict mode"
 // @Success      204  "No Content"
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Failure      428  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /v1/employees/{id} [delete]
 func (h *Handler) DeleteEmployee(c *gin.Context) {
@@ -120,7 +189,10 @@ func (h *Handler) DeleteEmployee(c *gin.Context) {
 		return
 	}
 
-	if err := h.processors.EmployeeProcessor.DeleteEmployee(id); err != nil {
+	if err := h.processors.EmployeeProcessor.DeleteEmployee(id, parseIfMatch(c)); err != nil {
+		if respondPreconditionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
 		return
 	}
@@ -130,15 +202,20 @@ func (h *Handler) DeleteEmployee(c *gin.Context) {
 
 // UpdateEmployee
 // @Summary      Update employee
-// @Description  Updates employee by ID
+// @Description  Updates employee by ID. With If-Match the update only happens if the employee was not modified since that ETag.
 // @Tags         employees
 // @Accept       json
 // @Produce      json
-// @Param        id        path      int              true  "Employee ID"
-// @Param        employee  body      models.Employee  true  "Employee"
+// @Param        id        path      int              true   "Employee ID"
+// @Param        If-Match  header    string           false  "ETag of the version being replaced; required in strict mode"
+// @Param        employee  body      models.Employee  true   "Employee"
 // @Success      200  {object}  models.Employee
+// @Header       200  {string}  ETag  "New version"
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string  "Name already taken"
+// @Failure      412  {object}  map[string]string
+// @Failure      428  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /v1/employees/{id} [put]
 func (h *Handler) UpdateEmployee(c *gin.Context) {
@@ -155,12 +232,99 @@ func (h *Handler) UpdateEmployee(c *gin.Context) {
 		return
 	}
 
-	err = h.processors.EmployeeProcessor.UpdateEmployee(id, employee.Name, employee.Position, employee.Department, employee.Salary)
+	updated, err := h.processors.EmployeeProcessor.UpdateEmployee(id, parseIfMatch(c), employee.Name, employee.Position, employee.Department, employee.Salary)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		if respondPreconditionError(c, err) {
+			return
+		}
+		errMsg := err.Error()
+
+		switch {
+		case strings.Contains(errMsg, "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": errMsg})
+		case strings.Contains(errMsg, "cannot be negative"), strings.Contains(errMsg, "is required"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		case strings.Contains(errMsg, "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(updated.Version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// PatchEmployee
+// @Summary      Partially update employee
+// @Description  Updates only the fields changed by the patch. The body is a JSON Merge Patch (application/merge-patch+json, also assumed for application/json) or a JSON Patch (application/json-patch+json).
+// @Description  The patched employee is validated like a new one; id, created_at and updated_at are read-only.
+// @Tags         employees
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path      int     true   "Employee ID"
+// @Param        If-Match  header    string  false  "ETag of the version being patched; required in strict mode"
+// @Param        patch     body      object  true   "Merge patch object or JSON Patch operation array"
+// @Success      200  {object}  models.Employee
+// @Header       200  {string}  ETag  "New version"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string  "Name already taken or a test operation failed"
+// @Failure      412  {object}  map[string]string
+// @Failure      415  {object}  map[string]string
+// @Failure      428  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/employees/{id} [patch]
+func (h *Handler) PatchEmployee(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	var patchType models.PatchType
+	switch c.ContentType() {
+	case string(models.PatchTypeMerge), "application/json":
+		patchType = models.PatchTypeMerge
+	case string(models.PatchTypeJSON):
+		patchType = models.PatchTypeJSON
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/merge-patch+json or application/json-patch+json"})
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	employee, err := h.processors.EmployeeProcessor.PatchEmployee(id, parseIfMatch(c), patch, patchType)
+	if err != nil {
+		if respondPreconditionError(c, err) {
+			return
+		}
+		errMsg := err.Error()
+
+		switch {
+		case errors.Is(err, processors.ErrPatchTestFailed), strings.Contains(errMsg, "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": errMsg})
+		case errors.Is(err, processors.ErrInvalidPatch),
+			strings.Contains(errMsg, "cannot be negative"),
+			strings.Contains(errMsg, "is required"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		case strings.Contains(errMsg, "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
+		}
 		return
 	}
 
-	employee.ID = id
+	c.Header("ETag", versionETag(employee.Version))
 	c.JSON(http.StatusOK, employee)
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+// versionETag formats a row version as a strong entity tag.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the If-Match header. Weak and malformed tags never match,
+// because If-Match uses the strong comparison.
+func parseIfMatch(c *gin.Context) models.IfMatch {
+	values := c.Request.Header.Values("If-Match")
+	if len(values) == 0 {
+		return models.IfMatch{}
+	}
+
+	ifMatch := models.IfMatch{Present: true}
+	for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			ifMatch.Any = true
+			continue
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			ifMatch.Versions = append(ifMatch.Versions, version)
+		}
+	}
+	return ifMatch
+}
+
+// respondPreconditionError answers a missing or failed If-Match precondition and
+// reports whether err was one.
+func respondPreconditionError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, processors.ErrEmployeeVersionRequired):
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the current ETag is required"})
+	case errors.Is(err, repositories.ErrEmployeeVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Employee was modified; fetch it again and retry with the new ETag"})
+	default:
+		return false
+	}
+	return true
+}
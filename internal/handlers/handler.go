@@ -6,15 +6,33 @@ import (
 )
 
 type Handler struct {
-	processors *processors.Processors
-	Rsa        *RsaHandler
-	Aes        *AesHandler
+	processors   *processors.Processors
+	Rsa          *RsaHandler
+	Aes          *AesHandler
+	Hmac         *HmacHandler
+	Passwords    *PasswordHandler
+	Kdf          *KdfHandler
+	Ec           *EcHandler
+	X25519       *X25519Handler
+	Keys         *KeyHandler
+	Certificates *CertificateHandler
+	Ca           *CaHandler
 }
 
-func NewHandler(p *processors.Processors, keyStorage repositories.IKeyStorage) *Handler {
+func NewHandler(p *processors.Processors, keyStorage repositories.IKeyStorage, aesKeyStorage repositories.IAesKeyStorage,
+	hmacKeyStorage repositories.IHmacKeyStorage, secretKeyStorage repositories.ISecretKeyStorage,
+	certificateStorage repositories.ICertificateStorage, caStorage repositories.ICertificateAuthorityStorage) *Handler {
 	return &Handler{
-		processors: p,
-		Rsa:        NewRsaHandler(p.Rsa, keyStorage),
-		Aes:        NewAesHandler(p.Aes),
+		processors:   p,
+		Rsa:          NewRsaHandler(p.Rsa, keyStorage),
+		Aes:          NewAesHandler(p.Aes, p.AesStream, aesKeyStorage),
+		Hmac:         NewHmacHandler(p.Hmac, hmacKeyStorage),
+		Passwords:    NewPasswordHandler(p.Passwords),
+		Kdf:          NewKdfHandler(p.Kdf, secretKeyStorage),
+		Ec:           NewEcHandler(p.Ec, keyStorage),
+		X25519:       NewX25519Handler(p.X25519, keyStorage),
+		Keys:         NewKeyHandler(p, keyStorage),
+		Certificates: NewCertificateHandler(p.Certificates, keyStorage, certificateStorage),
+		Ca:           NewCaHandler(p.Ca, keyStorage, certificateStorage, caStorage),
 	}
 }
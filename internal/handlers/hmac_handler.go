@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+type HmacHandler struct {
+	HmacService processors.IHmacService
+	KeyStorage  repositories.IHmacKeyStorage
+}
+
+func NewHmacHandler(service processors.IHmacService, storage repositories.IHmacKeyStorage) *HmacHandler {
+	return &HmacHandler{HmacService: service, KeyStorage: storage}
+}
+
+// GenerateKeys generates a new HMAC key bound to an algorithm, stores it and returns
+// its metadata. Like AES keys, the secret never leaves the server.
+func (h *HmacHandler) GenerateKeys(c *gin.Context) {
+	var req struct {
+		Algorithm models.HmacAlgorithm `json:"algorithm"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+
+	key, err := h.HmacService.GenerateKey(req.Algorithm)
+	if err != nil {
+		if errors.Is(err, processors.ErrUnsupportedHmacAlgorithm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate key: %s", err)})
+		return
+	}
+
+	meta, err := h.KeyStorage.SaveHmacKey(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save key to storage", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// ListKeys returns metadata of all stored HMAC keys.
+func (h *HmacHandler) ListKeys(c *gin.Context) {
+	keys, err := h.KeyStorage.ListHmacKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// GetKeyMetadata returns metadata of a single stored HMAC key.
+func (h *HmacHandler) GetKeyMetadata(c *gin.Context) {
+	keyID := c.Param("keyId")
+
+	meta, err := h.KeyStorage.GetHmacKeyMetadata(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// DisableKey marks a stored HMAC key as disabled so it can no longer sign or verify.
+func (h *HmacHandler) DisableKey(c *gin.Context) {
+	keyID := c.Param("keyId")
+
+	meta, err := h.KeyStorage.DisableHmacKey(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// Sign computes the MAC of a message with a stored key. If an algorithm is given
+// it must match the key's algorithm. The message is decoded with the request
+// encoding, or is the raw application/octet-stream body.
+func (h *HmacHandler) Sign(c *gin.Context) {
+	var req struct {
+		KeyID     string                 `json:"keyId" form:"keyId"`
+		Algorithm models.HmacAlgorithm   `json:"algorithm" form:"algorithm"`
+		Message   string                 `json:"message" form:"-"`
+		Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+	}
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if req.KeyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+
+	key, ok := h.loadActiveKey(c, req.KeyID, req.Algorithm)
+	if !ok {
+		return
+	}
+
+	mac, err := h.HmacService.Sign(key, message)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Signing failed: %s", err)})
+		return
+	}
+
+	respondBinary(c, mac, req.Encoding, func(value string) any {
+		return gin.H{"keyId": req.KeyID, "algorithm": key.Algorithm, "mac": value}
+	})
+}
+
+// Verify checks a MAC of a message in constant time. For a raw
+// application/octet-stream message the MAC is passed as the mac query parameter.
+func (h *HmacHandler) Verify(c *gin.Context) {
+	var req struct {
+		KeyID     string                 `json:"keyId" form:"keyId"`
+		Algorithm models.HmacAlgorithm   `json:"algorithm" form:"algorithm"`
+		Message   string                 `json:"message" form:"-"`
+		Mac       string                 `json:"mac" form:"mac"`
+		Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+	}
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if req.KeyID == "" || req.Mac == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+	mac, ok := decodeBinaryField(c, "mac", req.Mac, req.Encoding)
+	if !ok {
+		return
+	}
+
+	key, ok := h.loadActiveKey(c, req.KeyID, req.Algorithm)
+	if !ok {
+		return
+	}
+
+	valid, err := h.HmacService.Verify(key, message, mac)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Verification failed: %s", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": valid})
+}
+
+// loadActiveKey fetches a key from the vault and writes an error response if it
+// is missing, disabled or bound to a different algorithm.
+func (h *HmacHandler) loadActiveKey(c *gin.Context, keyID string, algorithm models.HmacAlgorithm) (models.HmacKey, bool) {
+	key, meta, err := h.KeyStorage.GetHmacKey(keyID)
+	if err != nil {
+		h.storageError(c, keyID, err)
+		return models.HmacKey{}, false
+	}
+
+	if meta.Status != models.AesKeyStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("HMAC key %s is %s.", keyID, meta.Status)})
+		return models.HmacKey{}, false
+	}
+	if algorithm != "" && algorithm != key.Algorithm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("HMAC key %s is an %s key.", keyID, key.Algorithm)})
+		return models.HmacKey{}, false
+	}
+	return key, true
+}
+
+func (h *HmacHandler) storageError(c *gin.Context, keyID string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("HMAC key %s not found.", keyID)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+}
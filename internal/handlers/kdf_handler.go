@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+// generatedSaltLength is the salt size used when a PBKDF2 request brings none.
+const generatedSaltLength = 16
+
+type KdfHandler struct {
+	KdfService processors.IKdfService
+	KeyStorage repositories.ISecretKeyStorage
+}
+
+func NewKdfHandler(service processors.IKdfService, storage repositories.ISecretKeyStorage) *KdfHandler {
+	return &KdfHandler{KdfService: service, KeyStorage: storage}
+}
+
+// GenerateMasterKey creates and stores a random KDF master key. Only these keys
+// can be used as masterKeyId; AES and HMAC keys are never accepted.
+func (h *KdfHandler) GenerateMasterKey(c *gin.Context) {
+	key, err := h.KdfService.GenerateMasterKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate key: %s", err)})
+		return
+	}
+
+	meta, err := h.KeyStorage.SaveKdfMasterKey(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save key to storage", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// HKDF derives a key from base64 input keying material or from a stored KDF master
+// key, so callers can re-derive subkeys on demand instead of storing them. A master
+// key always runs the full extract-and-expand with a fixed salt; only info varies.
+func (h *KdfHandler) HKDF(c *gin.Context) {
+	var req struct {
+		Mode            models.HkdfMode         `json:"mode"`
+		Hash            models.KdfHash          `json:"hash"`
+		KeyMaterial     string                  `json:"keyMaterial"`
+		MasterKeyID     string                  `json:"masterKeyId"`
+		Salt            string                  `json:"salt"`
+		Info            string                  `json:"info"`
+		TargetAlgorithm models.DerivedKeyTarget `json:"targetAlgorithm"`
+		Length          int                     `json:"length"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.KeyMaterial == "") == (req.MasterKeyID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload. Exactly one of 'keyMaterial' and 'masterKeyId' is required."})
+		return
+	}
+
+	if req.MasterKeyID != "" {
+		if req.Mode != "" && req.Mode != models.HkdfModeExtractAndExpand {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A masterKeyId only supports the extract-and-expand mode."})
+			return
+		}
+		if req.Salt != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A masterKeyId uses a fixed salt; vary 'info' to derive different keys."})
+			return
+		}
+		master, ok := h.loadMasterKey(c, req.MasterKeyID)
+		if !ok {
+			return
+		}
+
+		key, err := h.KdfService.DeriveFromMasterKey(req.Hash, master.Key, req.Info, req.TargetAlgorithm, req.Length)
+		if err != nil {
+			kdfError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"key": base64.StdEncoding.EncodeToString(key), "length": len(key)})
+		return
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(req.Salt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid salt. Must be base64 encoded."})
+		return
+	}
+	secret, err := base64.StdEncoding.DecodeString(req.KeyMaterial)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid keyMaterial. Must be base64 encoded."})
+		return
+	}
+
+	key, err := h.KdfService.HKDF(req.Mode, req.Hash, secret, salt, req.Info, req.TargetAlgorithm, req.Length)
+	if err != nil {
+		kdfError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"key": base64.StdEncoding.EncodeToString(key), "length": len(key)})
+}
+
+// PBKDF2 derives a key from a password. Without a salt a random one is generated
+// and returned; callers must keep it to derive the same key again.
+func (h *KdfHandler) PBKDF2(c *gin.Context) {
+	var req struct {
+		Hash            models.KdfHash          `json:"hash"`
+		Password        string                  `json:"password"`
+		Salt            string                  `json:"salt"`
+		Iterations      int                     `json:"iterations"`
+		TargetAlgorithm models.DerivedKeyTarget `json:"targetAlgorithm"`
+		Length          int                     `json:"length"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(req.Salt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid salt. Must be base64 encoded."})
+		return
+	}
+	if req.Iterations == 0 {
+		req.Iterations = processors.DefaultPBKDF2Iterations
+	}
+	if len(salt) == 0 {
+		salt = make([]byte, generatedSaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate salt: %s", err)})
+			return
+		}
+	}
+
+	key, err := h.KdfService.PBKDF2(req.Hash, req.Password, salt, req.Iterations, req.TargetAlgorithm, req.Length)
+	if err != nil {
+		kdfError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":        base64.StdEncoding.EncodeToString(key),
+		"length":     len(key),
+		"salt":       base64.StdEncoding.EncodeToString(salt),
+		"iterations": req.Iterations,
+	})
+}
+
+func (h *KdfHandler) loadMasterKey(c *gin.Context, keyID string) (models.SecretKeyMaterial, bool) {
+	master, err := h.KeyStorage.GetKdfMasterKey(keyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("KDF master key %s not found.", keyID)})
+			return models.SecretKeyMaterial{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return models.SecretKeyMaterial{}, false
+	}
+
+	if master.Status != models.AesKeyStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("KDF master key %s is %s.", keyID, master.Status)})
+		return models.SecretKeyMaterial{}, false
+	}
+	return master, true
+}
+
+func kdfError(c *gin.Context, err error) {
+	if errors.Is(err, processors.ErrInvalidKdfParameters) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Key derivation failed: %s", err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Key derivation failed: %s", err)})
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+// KeyHandler serves algorithm-independent operations on stored key pairs.
+type KeyHandler struct {
+	Processors *processors.Processors
+	KeyStorage repositories.IKeyStorage
+}
+
+func NewKeyHandler(p *processors.Processors, storage repositories.IKeyStorage) *KeyHandler {
+	return &KeyHandler{Processors: p, KeyStorage: storage}
+}
+
+// GetPublicKey returns the PEM public key and algorithm of any stored key pair.
+func (h *KeyHandler) GetPublicKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	pair, err := h.KeyStorage.GetPublicKey(id)
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// GetJWKS publishes the public keys of active and retired key pairs as a JWK Set.
+// Retired keys stay published so signatures they produced can still be verified.
+// Imported public-only keys are not ours to advertise and are left out.
+func (h *KeyHandler) GetJWKS(c *gin.Context) {
+	pairs, err := h.KeyStorage.ListPublicKeys(models.KeyStatusActive, models.KeyStatusRetired)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		return
+	}
+
+	set := models.JWKSet{Keys: make([]models.JWK, 0, len(pairs))}
+	for _, pair := range pairs {
+		if !pair.HasPrivateKey {
+			continue
+		}
+		jwk, err := processors.PublicKeyToJWK(pair)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to encode key %d as JWK: %s", pair.ID, err)})
+			return
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+type ImportKeyRequest struct {
+	Format models.KeyFormat `json:"format"`
+	// Key is a PEM string, base64 DER string, or a JWK given as an object or string.
+	Key        json.RawMessage  `json:"key"`
+	Passphrase string           `json:"passphrase"`
+	Status     models.KeyStatus `json:"status"`
+	NotBefore  *time.Time       `json:"notBefore"`
+	ExpiresAt  *time.Time       `json:"expiresAt"`
+}
+
+// ImportKey stores an externally generated private or public key, e.g. an HSM export.
+// Public-only imports can encrypt and verify but never decrypt or sign.
+func (h *KeyHandler) ImportKey(c *gin.Context) {
+	var req ImportKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Key) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.Status != "" && req.Status != models.KeyStatusActive && req.Status != models.KeyStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Imported keys must be active or pending"})
+		return
+	}
+	if !validValidityWindow(req.NotBefore, req.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be after notBefore"})
+		return
+	}
+
+	keyData := string(req.Key)
+	var encoded string
+	if err := json.Unmarshal(req.Key, &encoded); err == nil {
+		keyData = encoded
+	}
+
+	pair, err := h.Processors.Import.ImportKey(keyData, req.Format, req.Passphrase)
+	if err != nil {
+		keyFormatError(c, "Import failed", err)
+		return
+	}
+	pair.Status = req.Status
+	pair.NotBefore = req.NotBefore
+	pair.ExpiresAt = req.ExpiresAt
+
+	id, err := h.KeyStorage.SaveKeyPair(pair)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save keys to storage", "details": err.Error()})
+		return
+	}
+
+	stored, err := h.KeyStorage.GetPublicKey(id)
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stored)
+}
+
+type ExportKeyRequest struct {
+	Format models.KeyFormat `json:"format"`
+	// PKCS1 selects the RSA-specific PKCS#1 structure for the public key instead of PKIX.
+	PKCS1 bool `json:"pkcs1"`
+	// IncludePrivate must be set explicitly to export the private key, which is
+	// always encrypted with Passphrase.
+	IncludePrivate bool   `json:"includePrivate"`
+	Passphrase     string `json:"passphrase"`
+}
+
+// ExportKey exports a stored key pair so it can be migrated into other tools.
+func (h *KeyHandler) ExportKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	var req ExportKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if req.Passphrase != "" && !req.IncludePrivate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase is only used together with includePrivate"})
+		return
+	}
+
+	var pair models.KeyPair
+	if req.IncludePrivate {
+		pair, err = h.KeyStorage.GetKeyPair(id)
+	} else {
+		pair, err = h.KeyStorage.GetPublicKey(id)
+	}
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	publicKey, err := h.Processors.Export.ExportPublicKey(pair, req.Format, req.PKCS1)
+	if err != nil {
+		keyFormatError(c, "Export failed", err)
+		return
+	}
+	resp := gin.H{"id": pair.ID, "algorithm": pair.Algorithm, "publicKey": publicKey}
+
+	if req.IncludePrivate {
+		if pair.Status == models.KeyStatusRevoked || !pair.HasPrivateKey {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The private key of key pair %d cannot be exported (status %s).", id, pair.Status)})
+			return
+		}
+		privateKey, err := h.Processors.Export.ExportPrivateKey(pair, req.Format, req.Passphrase)
+		if err != nil {
+			keyFormatError(c, "Export failed", err)
+			return
+		}
+		resp["privateKey"] = privateKey
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// keyFormatError maps key parsing and encoding errors caused by the request to 400.
+func keyFormatError(c *gin.Context, message string, err error) {
+	if errors.Is(err, processors.ErrInvalidKeyData) || errors.Is(err, processors.ErrUnsupportedKeyFormat) ||
+		errors.Is(err, processors.ErrKeyPassphrase) || errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) ||
+		errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+}
+
+type RotateKeyRequest struct {
+	NotBefore *time.Time `json:"notBefore"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// RotateKey generates a new active key pair of the same algorithm and retires the old one.
+// The retired key can still decrypt and verify; the response points at its replacement.
+func (h *KeyHandler) RotateKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	var req RotateKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if !validValidityWindow(req.NotBefore, req.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be after notBefore"})
+		return
+	}
+
+	current, err := h.KeyStorage.GetPublicKey(id)
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+	if !current.HasPrivateKey {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Key pair with ID %d has no private key and cannot be rotated.", id)})
+		return
+	}
+
+	// Keep the key size unless the policy no longer allows it.
+	keySize := 0
+	if current.Algorithm == models.KeyAlgorithmRSA && h.Processors.Rsa.KeyPolicy().Allows(current.KeySize) {
+		keySize = current.KeySize
+	}
+
+	replacement, err := h.Processors.GenerateKeyPair(current.Algorithm, keySize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate replacement key", "details": err.Error()})
+		return
+	}
+	replacement.NotBefore = req.NotBefore
+	replacement.ExpiresAt = req.ExpiresAt
+
+	rotated, err := h.KeyStorage.RotateKeyPair(id, replacement)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidKeyState) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"retiredId": id, "key": rotated})
+}
+
+type UpdateKeyStatusRequest struct {
+	Status models.KeyStatus `json:"status" binding:"required"`
+}
+
+// UpdateKeyStatus moves a key pair to a new lifecycle status, e.g. to revoke a
+// compromised key or destroy its private key material.
+func (h *KeyHandler) UpdateKeyStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	var req UpdateKeyStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	current, err := h.KeyStorage.GetPublicKey(id)
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+	if err := processors.CheckKeyTransition(current.Status, req.Status); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.KeyStorage.UpdateKeyStatus(id, current.Status, req.Status)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidKeyState) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+type UpdateKeyValidityRequest struct {
+	NotBefore *time.Time `json:"notBefore"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// UpdateKeyValidity replaces the not_before / expires_at window of a key pair.
+// Omitted fields clear the corresponding bound.
+func (h *KeyHandler) UpdateKeyValidity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return
+	}
+
+	var req UpdateKeyValidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if !validValidityWindow(req.NotBefore, req.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be after notBefore"})
+		return
+	}
+
+	pair, err := h.KeyStorage.UpdateKeyValidity(id, req.NotBefore, req.ExpiresAt)
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+func validValidityWindow(notBefore, expiresAt *time.Time) bool {
+	return notBefore == nil || expiresAt == nil || expiresAt.After(*notBefore)
+}
+
+// loadKeyPair fetches a stored key pair for an operation and checks its algorithm
+// and lifecycle. Only decrypt and sign load the private key. It writes an error
+// response and returns false when the key cannot be used.
+func loadKeyPair(c *gin.Context, storage repositories.IKeyStorage, id int, operation models.KeyOperation, algorithms ...models.KeyAlgorithm) (models.KeyPair, bool) {
+	var pair models.KeyPair
+	var err error
+	if operation == models.KeyOperationDecrypt || operation == models.KeyOperationSign {
+		pair, err = storage.GetKeyPair(id)
+	} else {
+		pair, err = storage.GetPublicKey(id)
+	}
+	if err != nil {
+		keyPairStorageError(c, id, err)
+		return models.KeyPair{}, false
+	}
+
+	if len(algorithms) > 0 && !slices.Contains(algorithms, pair.Algorithm) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Key pair with ID %d is a %s key and cannot be used here.", id, pair.Algorithm)})
+		return models.KeyPair{}, false
+	}
+
+	if err := processors.CheckKeyUsage(pair, operation, time.Now()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return models.KeyPair{}, false
+	}
+
+	if (operation == models.KeyOperationDecrypt || operation == models.KeyOperationSign) && !pair.HasPrivateKey {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Key pair with ID %d has no private key.", id)})
+		return models.KeyPair{}, false
+	}
+
+	return pair, true
+}
+
+func keyPairStorageError(c *gin.Context, id int, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Key pair with ID %d not found.", id)})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+)
+
+type PasswordHandler struct {
+	Hasher processors.IPasswordHasher
+}
+
+func NewPasswordHandler(hasher processors.IPasswordHasher) *PasswordHandler {
+	return &PasswordHandler{Hasher: hasher}
+}
+
+// Hash hashes a password with Argon2id or bcrypt; the server policy picks the
+// algorithm when none is given and always picks the cost parameters.
+func (h *PasswordHandler) Hash(c *gin.Context) {
+	var req struct {
+		Password  string                   `json:"password"`
+		Algorithm models.PasswordAlgorithm `json:"algorithm"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload. 'password' is required."})
+		return
+	}
+
+	hash, err := h.Hasher.Hash(req.Password, req.Algorithm)
+	if err != nil {
+		passwordError(c, "Hashing failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hash": hash})
+}
+
+// Verify checks a password against a hash. needsRehash tells the caller to store
+// a new hash (from /hash) after a successful login because the policy changed.
+func (h *PasswordHandler) Verify(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+		Hash     string `json:"hash"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Hash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload. 'hash' is required."})
+		return
+	}
+
+	valid, needsRehash, err := h.Hasher.Verify(req.Password, req.Hash)
+	if err != nil {
+		passwordError(c, "Verification failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": valid, "needsRehash": needsRehash})
+}
+
+func passwordError(c *gin.Context, message string, err error) {
+	if errors.Is(err, processors.ErrInvalidPasswordHash) || errors.Is(err, processors.ErrInvalidPassword) ||
+		errors.Is(err, processors.ErrUnsupportedPasswordAlgorithm) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+)
+
+const (
+	mimeOctetStream = "application/octet-stream"
+	// maxRawPayloadSize bounds raw request bodies, which are processed in memory.
+	// Larger files belong on the streaming endpoints.
+	maxRawPayloadSize = 16 << 20
+)
+
+// bindPayloadRequest binds a crypto request. A JSON body is bound into req. A raw
+// application/octet-stream body is the payload itself: it is returned as raw and the
+// other parameters are bound from the query string. raw is nil for JSON requests.
+// Private keys are never bound from the query string, where access logs would record
+// them; raw requests must name a stored keyId instead.
+// It writes an error response and returns false when the request cannot be bound.
+func bindPayloadRequest(c *gin.Context, req any) (raw []byte, ok bool) {
+	if c.ContentType() != mimeOctetStream {
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if _, found := c.GetQuery("privateKey"); found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Private keys must not be sent in the URL; use a stored keyId with raw request bodies."})
+		return nil, false
+	}
+
+	if err := c.ShouldBindQuery(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid query parameters: %s", err)})
+		return nil, false
+	}
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRawPayloadSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Raw payload exceeds %d bytes; use the streaming endpoints.", maxRawPayloadSize)})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to read request body: %s", err)})
+		return nil, false
+	}
+	if raw == nil {
+		raw = []byte{}
+	}
+	return raw, true
+}
+
+// requestPayload returns the raw body of a raw request, or the named JSON field
+// decoded with the request encoding.
+func requestPayload(c *gin.Context, raw []byte, field, value string, encoding models.PayloadEncoding) ([]byte, bool) {
+	if raw != nil {
+		return raw, true
+	}
+	return decodePayloadField(c, field, value, encoding)
+}
+
+// decodePayloadField decodes a payload field and writes a 400 response if it does not match the encoding.
+func decodePayloadField(c *gin.Context, field, value string, encoding models.PayloadEncoding) ([]byte, bool) {
+	data, err := processors.DecodePayload(value, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s: %s", field, err)})
+		return nil, false
+	}
+	return data, true
+}
+
+// acceptsRawPayload reports whether the client asked for the raw result bytes
+// with Accept: application/octet-stream. JSON stays the default.
+func acceptsRawPayload(c *gin.Context) bool {
+	return c.NegotiateFormat(binding.MIMEJSON, mimeOctetStream) == mimeOctetStream
+}
+
+// respondPlaintext answers with the decrypted bytes, raw or as the plainText
+// field in the request encoding.
+func respondPlaintext(c *gin.Context, plaintext []byte, encoding models.PayloadEncoding) {
+	if acceptsRawPayload(c) {
+		c.Data(http.StatusOK, mimeOctetStream, plaintext)
+		return
+	}
+
+	plainText, err := processors.EncodePayload(plaintext, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot encode plaintext: %s", err)})
+		return
+	}
+	c.JSON(http.StatusOK, DecryptionResponse{PlainText: plainText})
+}
+
+// decodeBinaryField decodes a binary field (a ciphertext, signature or MAC) with the
+// request encoding into the standard base64 the processors take. It writes a 400
+// response if the value does not match the encoding.
+func decodeBinaryField(c *gin.Context, field, value string, encoding models.PayloadEncoding) (string, bool) {
+	data, err := processors.DecodeBinaryPayload(value, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s: %s", field, err)})
+		return "", false
+	}
+	return base64.StdEncoding.EncodeToString(data), true
+}
+
+// requestBinary returns the raw body of a raw request, or the named binary JSON
+// field decoded with the request encoding, as standard base64.
+func requestBinary(c *gin.Context, raw []byte, field, value string, encoding models.PayloadEncoding) (string, bool) {
+	if raw != nil {
+		return base64.StdEncoding.EncodeToString(raw), true
+	}
+	return decodeBinaryField(c, field, value, encoding)
+}
+
+// respondBinary answers with the raw bytes of a base64 result (a ciphertext,
+// signature or MAC) when the client accepts them, or with the JSON object built
+// by result from the value in the request encoding.
+func respondBinary(c *gin.Context, base64Value string, encoding models.PayloadEncoding, result func(value string) any) {
+	data, err := base64.StdEncoding.DecodeString(base64Value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to encode result: %s", err)})
+		return
+	}
+	if acceptsRawPayload(c) {
+		c.Data(http.StatusOK, mimeOctetStream, data)
+		return
+	}
+
+	value, err := processors.EncodeBinaryPayload(data, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot encode result: %s", err)})
+		return
+	}
+	c.JSON(http.StatusOK, result(value))
+}
@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"laba6/internal/models"
 	"laba6/internal/processors"
 	"laba6/internal/repositories"
 )
@@ -21,12 +25,31 @@ func NewRsaHandler(service processors.IRsaService, storage repositories.IKeyStor
 	return &RsaHandler{RsaService: service, KeyStorage: storage}
 }
 
+type GenerateRsaKeysRequest struct {
+	Bits           int `json:"bits"`
+	PublicExponent int `json:"publicExponent"`
+}
+
+// GenerateRsaKeys generates and stores an RSA key pair. The optional body selects
+// a key size allowed by the server policy; the default size is used otherwise.
 func (h *RsaHandler) GenerateRsaKeys(c *gin.Context) {
-	keys, err := h.RsaService.GenerateCryptoKeys()
-	if err != nil {
+	var req GenerateRsaKeysRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+
+	keys, err := h.RsaService.GenerateCryptoKeysWithParams(req.Bits, req.PublicExponent)
+	if errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to generate RSA keys", "details": err.Error()})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate RSA keys", "details": err.Error()})
+		return
+	}
 
 	id, err := h.KeyStorage.SaveRsaKeys(keys)
 	if err != nil {
@@ -34,7 +57,7 @@ func (h *RsaHandler) GenerateRsaKeys(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id": id})
+	c.JSON(http.StatusOK, gin.H{"id": id, "keySize": keys.KeySize})
 }
 
 func (h *RsaHandler) GetRsaPublicKey(c *gin.Context) {
@@ -47,12 +70,7 @@ func (h *RsaHandler) GetRsaPublicKey(c *gin.Context) {
 
 	publicKey, err := h.KeyStorage.GetRsaPublicKey(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("RSA key pair with ID %d not found.", id)})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
+		keyStorageError(c, id, err)
 		return
 	}
 
@@ -60,55 +78,416 @@ func (h *RsaHandler) GetRsaPublicKey(c *gin.Context) {
 }
 
 type EncryptionRequest struct {
-	PublicKey string `json:"publicKey"`
-	PlainText string `json:"plainText"`
+	PublicKey string                 `json:"publicKey" form:"publicKey"`
+	PlainText string                 `json:"plainText" form:"-"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
 }
 
 type EncryptionResponse struct {
 	CipherText string `json:"cipherText"`
 }
 
+// Encrypt encrypts with a supplied PEM public key. The plaintext is decoded with the
+// request encoding, or is the raw application/octet-stream body.
 func (h *RsaHandler) Encrypt(c *gin.Context) {
 	var req EncryptionRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	plaintext, ok := requestPayload(c, raw, "plainText", req.PlainText, req.Encoding)
+	if !ok {
 		return
 	}
 
-	cipherText, err := h.RsaService.Encrypt(req.PublicKey, req.PlainText)
+	cipherText, err := h.RsaService.Encrypt(req.PublicKey, plaintext)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+		encryptionError(c, err)
 		return
 	}
 
-	resp := EncryptionResponse{CipherText: cipherText}
-	c.JSON(http.StatusOK, resp)
+	respondBinary(c, cipherText, req.Encoding, func(value string) any { return EncryptionResponse{CipherText: value} })
 }
 
 type DecryptionRequest struct {
-	PrivateKey       string `json:"privateKey"`
-	CipherTextBase64 string `json:"cipherText"`
+	PrivateKey       string                 `json:"privateKey" form:"-"`
+	CipherTextBase64 string                 `json:"cipherText" form:"-"`
+	Encoding         models.PayloadEncoding `json:"encoding" form:"encoding"`
 }
 
 type DecryptionResponse struct {
 	PlainText string `json:"plainText"`
 }
 
+// Decrypt decrypts with a PEM private key supplied in a JSON body. Raw
+// application/octet-stream ciphertexts carry no private key and go to
+// DecryptWithStoredKey instead.
 func (h *RsaHandler) Decrypt(c *gin.Context) {
 	var req DecryptionRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if req.PrivateKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "privateKey is required; decrypt raw ciphertexts with a stored key at /rsa/{id}/decrypt"})
+		return
+	}
+	if req.CipherTextBase64, ok = requestBinary(c, raw, "cipherText", req.CipherTextBase64, req.Encoding); !ok {
+		return
+	}
+
+	plaintext, err := h.RsaService.Decrypt(req.PrivateKey, req.CipherTextBase64)
+	if err != nil {
+		decryptionError(c, err)
+		return
+	}
+
+	respondPlaintext(c, plaintext, req.Encoding)
+}
+
+type StoredKeyEncryptionRequest struct {
+	PlainText string                 `json:"plainText" form:"-"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+type StoredKeyDecryptionRequest struct {
+	CipherTextBase64 string                 `json:"cipherText" form:"-"`
+	Encoding         models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// EncryptWithStoredKey encrypts with the public half of a stored key pair.
+func (h *RsaHandler) EncryptWithStoredKey(c *gin.Context) {
+	keys, ok := h.loadStoredKeys(c, models.KeyOperationEncrypt)
+	if !ok {
+		return
+	}
+
+	var req StoredKeyEncryptionRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	plaintext, ok := requestPayload(c, raw, "plainText", req.PlainText, req.Encoding)
+	if !ok {
+		return
+	}
+
+	cipherText, err := h.RsaService.Encrypt(keys.PublicKey, plaintext)
+	if err != nil {
+		encryptionError(c, err)
+		return
+	}
+
+	respondBinary(c, cipherText, req.Encoding, func(value string) any { return EncryptionResponse{CipherText: value} })
+}
+
+// DecryptWithStoredKey decrypts with the private half of a stored key pair,
+// so the private key never has to leave the server.
+func (h *RsaHandler) DecryptWithStoredKey(c *gin.Context) {
+	keys, ok := h.loadStoredKeys(c, models.KeyOperationDecrypt)
+	if !ok {
+		return
+	}
+
+	var req StoredKeyDecryptionRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if req.CipherTextBase64, ok = requestBinary(c, raw, "cipherText", req.CipherTextBase64, req.Encoding); !ok {
+		return
+	}
+
+	plaintext, err := h.RsaService.Decrypt(keys.PrivateKey, req.CipherTextBase64)
+	if err != nil {
+		decryptionError(c, err)
+		return
+	}
+
+	respondPlaintext(c, plaintext, req.Encoding)
+}
+
+type SignRequest struct {
+	KeyID      *int                   `json:"keyId" form:"keyId"`
+	PrivateKey string                 `json:"privateKey" form:"-"`
+	Message    string                 `json:"message" form:"-"`
+	Scheme     models.SignatureScheme `json:"scheme" form:"scheme"`
+	Hash       models.HashAlgorithm   `json:"hash" form:"hash"`
+	Encoding   models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+type SignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Sign signs a message with either a supplied PEM private key or a stored key pair.
+// The message is decoded with the request encoding, or is the raw application/octet-stream body.
+func (h *RsaHandler) Sign(c *gin.Context) {
+	var req SignRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PrivateKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or privateKey must be provided"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+
+	privateKey := req.PrivateKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationSign, models.KeyAlgorithmRSA)
+		if !ok {
+			return
+		}
+		privateKey = pair.PrivateKey
+	}
+
+	signature, err := h.RsaService.Sign(privateKey, message, req.Scheme, req.Hash)
+	if err != nil {
+		signatureError(c, "Signing failed", err)
+		return
+	}
+
+	respondBinary(c, signature, req.Encoding, func(value string) any { return SignResponse{Signature: value} })
+}
+
+type VerifyRequest struct {
+	KeyID     *int                   `json:"keyId" form:"keyId"`
+	PublicKey string                 `json:"publicKey" form:"publicKey"`
+	Message   string                 `json:"message" form:"-"`
+	Signature string                 `json:"signature" form:"signature"`
+	Scheme    models.SignatureScheme `json:"scheme" form:"scheme"`
+	Hash      models.HashAlgorithm   `json:"hash" form:"hash"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+type VerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// Verify checks a signature with either a supplied PEM public key or a stored key pair.
+// For a raw application/octet-stream message the signature is a query parameter.
+func (h *RsaHandler) Verify(c *gin.Context) {
+	var req VerifyRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PublicKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or publicKey must be provided"})
+		return
+	}
+	message, ok := requestPayload(c, raw, "message", req.Message, req.Encoding)
+	if !ok {
+		return
+	}
+	signature, ok := decodeBinaryField(c, "signature", req.Signature, req.Encoding)
+	if !ok {
+		return
+	}
+
+	publicKey := req.PublicKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationVerify, models.KeyAlgorithmRSA)
+		if !ok {
+			return
+		}
+		publicKey = pair.PublicKey
+	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	valid, err := h.RsaService.Verify(publicKey, message, signature, req.Scheme, req.Hash)
+	if err != nil {
+		signatureError(c, "Verification failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyResponse{Valid: valid})
+}
+
+func signatureError(c *gin.Context, message string, err error) {
+	if errors.Is(err, processors.ErrUnsupportedSignatureScheme) || errors.Is(err, processors.ErrUnsupportedHash) ||
+		errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) || errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %s", message, err)})
+}
+
+// encryptionError reports a failed encryption; keys rejected by the policy are client errors.
+func encryptionError(c *gin.Context, err error) {
+	if errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+}
+
+// decryptionError reports a failed decryption; ciphertexts that do not decrypt are client errors.
+func decryptionError(c *gin.Context, err error) {
+	if errors.Is(err, processors.ErrRsaDecryptionFailed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+}
+
+type HybridEncryptionRequest struct {
+	KeyID     *int                   `json:"keyId" form:"keyId"`
+	PublicKey string                 `json:"publicKey" form:"publicKey"`
+	PlainText string                 `json:"plainText" form:"-"`
+	Format    models.EnvelopeFormat  `json:"format" form:"format"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+type HybridDecryptionRequest struct {
+	KeyID      *int   `json:"keyId" form:"keyId"`
+	PrivateKey string `json:"privateKey" form:"-"`
+	// Envelope is either a JSON envelope object or a binary envelope in the request encoding.
+	Envelope json.RawMessage        `json:"envelope" form:"-"`
+	Encoding models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// HybridEncrypt encrypts payloads of any size with an ephemeral AES-GCM key wrapped by RSA-OAEP.
+// With Accept: application/octet-stream the compact binary envelope is returned raw.
+func (h *RsaHandler) HybridEncrypt(c *gin.Context) {
+	var req HybridEncryptionRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PublicKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or publicKey must be provided"})
+		return
+	}
+	if req.Format != "" && req.Format != models.EnvelopeFormatJSON && req.Format != models.EnvelopeFormatBinary {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported envelope format %q", req.Format)})
+		return
+	}
+	plaintext, ok := requestPayload(c, raw, "plainText", req.PlainText, req.Encoding)
+	if !ok {
+		return
+	}
+
+	publicKey := req.PublicKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationEncrypt, models.KeyAlgorithmRSA)
+		if !ok {
+			return
+		}
+		publicKey = pair.PublicKey
+	}
+
+	envelope, err := h.RsaService.HybridEncrypt(publicKey, plaintext)
+	if err != nil {
+		encryptionError(c, err)
+		return
+	}
+
+	if req.Format == models.EnvelopeFormatBinary || acceptsRawPayload(c) {
+		compact, err := processors.MarshalHybridEnvelope(envelope)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+			return
+		}
+		encoded := base64.StdEncoding.EncodeToString(compact)
+		respondBinary(c, encoded, req.Encoding, func(value string) any { return gin.H{"envelope": value} })
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"envelope": envelope})
+}
+
+// HybridDecrypt decrypts a JSON or binary hybrid envelope. A raw
+// application/octet-stream body is the compact binary envelope.
+func (h *RsaHandler) HybridDecrypt(c *gin.Context) {
+	var req HybridDecryptionRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if raw == nil && len(req.Envelope) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
+	if (req.KeyID == nil) == (req.PrivateKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or privateKey must be provided"})
+		return
+	}
+
+	var envelope models.HybridEnvelope
+	var err error
+	if raw != nil {
+		envelope, err = processors.UnmarshalHybridEnvelope(raw)
+	} else {
+		envelope, err = parseHybridEnvelope(req.Envelope, req.Encoding)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid envelope: %s", err)})
+		return
+	}
+
+	privateKey := req.PrivateKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationDecrypt, models.KeyAlgorithmRSA)
+		if !ok {
+			return
+		}
+		privateKey = pair.PrivateKey
+	}
 
-	plainText, err := h.RsaService.Decrypt(req.PrivateKey, req.CipherTextBase64)
+	plaintext, err := h.RsaService.HybridDecrypt(privateKey, envelope)
 	if err != nil {
+		if errors.Is(err, processors.ErrAesAuthenticationFailed) || errors.Is(err, processors.ErrInvalidHybridEnvelope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
 		return
 	}
 
-	resp := DecryptionResponse{PlainText: plainText}
-	c.JSON(http.StatusOK, resp)
+	respondPlaintext(c, plaintext, req.Encoding)
+}
+
+// parseHybridEnvelope accepts either a JSON envelope object or a string holding
+// the compact binary envelope in the request encoding.
+func parseHybridEnvelope(raw json.RawMessage, encoding models.PayloadEncoding) (models.HybridEnvelope, error) {
+	var compact string
+	if err := json.Unmarshal(raw, &compact); err == nil {
+		data, err := processors.DecodeBinaryPayload(compact, encoding)
+		if err != nil {
+			return models.HybridEnvelope{}, fmt.Errorf("failed to decode envelope: %w", err)
+		}
+		return processors.UnmarshalHybridEnvelope(data)
+	}
+
+	var envelope models.HybridEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return models.HybridEnvelope{}, err
+	}
+	return envelope, nil
+}
+
+// loadStoredKeys resolves the :id path parameter to a stored RSA key pair usable
+// for the operation and writes an error response when it cannot.
+func (h *RsaHandler) loadStoredKeys(c *gin.Context, operation models.KeyOperation) (models.KeyPair, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format. Must be an integer."})
+		return models.KeyPair{}, false
+	}
+
+	return loadKeyPair(c, h.KeyStorage, id, operation, models.KeyAlgorithmRSA)
+}
+
+func keyStorageError(c *gin.Context, id int, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("RSA key pair with ID %d not found.", id)})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Internal storage error: %s", err.Error())})
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"laba6/internal/repositories"
+)
+
+type X25519Handler struct {
+	X25519Service processors.IX25519Service
+	KeyStorage    repositories.IKeyStorage
+}
+
+func NewX25519Handler(service processors.IX25519Service, storage repositories.IKeyStorage) *X25519Handler {
+	return &X25519Handler{X25519Service: service, KeyStorage: storage}
+}
+
+// GenerateKeys generates and stores an X25519 key pair and returns its public key,
+// which clients use to seal messages to us.
+func (h *X25519Handler) GenerateKeys(c *gin.Context) {
+	pair, err := h.X25519Service.GenerateKeyPair()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate X25519 keys", "details": err.Error()})
+		return
+	}
+
+	id, err := h.KeyStorage.SaveKeyPair(pair)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save keys to storage", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "algorithm": pair.Algorithm, "publicKey": pair.PublicKey})
+}
+
+type SealRequest struct {
+	KeyID     *int                   `json:"keyId" form:"keyId"`
+	PublicKey string                 `json:"publicKey" form:"publicKey"`
+	PlainText string                 `json:"plainText" form:"-"`
+	Cipher    models.SealedBoxCipher `json:"cipher" form:"cipher"`
+	Encoding  models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// Seal encrypts a message to a stored or supplied X25519 public key. The message is
+// decoded with the request encoding, or is the raw application/octet-stream body.
+func (h *X25519Handler) Seal(c *gin.Context) {
+	var req SealRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PublicKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or publicKey must be provided"})
+		return
+	}
+	plaintext, ok := requestPayload(c, raw, "plainText", req.PlainText, req.Encoding)
+	if !ok {
+		return
+	}
+
+	publicKey := req.PublicKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationEncrypt, models.KeyAlgorithmX25519)
+		if !ok {
+			return
+		}
+		publicKey = pair.PublicKey
+	}
+
+	sealed, err := h.X25519Service.Seal(publicKey, plaintext, req.Cipher)
+	if err != nil {
+		if errors.Is(err, processors.ErrUnsupportedSealedBoxCipher) || errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) ||
+			errors.Is(err, processors.ErrInvalidX25519Key) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Encryption failed: %s", err)})
+		return
+	}
+
+	respondBinary(c, sealed, req.Encoding, func(value string) any { return gin.H{"sealed": value} })
+}
+
+type OpenRequest struct {
+	KeyID      *int                   `json:"keyId" form:"keyId"`
+	PrivateKey string                 `json:"privateKey" form:"-"`
+	Sealed     string                 `json:"sealed" form:"-"`
+	Encoding   models.PayloadEncoding `json:"encoding" form:"encoding"`
+}
+
+// Open decrypts a sealed box with a stored or supplied X25519 private key. A raw
+// application/octet-stream body is the binary sealed box.
+func (h *X25519Handler) Open(c *gin.Context) {
+	var req OpenRequest
+	raw, ok := bindPayloadRequest(c, &req)
+	if !ok {
+		return
+	}
+	if (req.KeyID == nil) == (req.PrivateKey == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of keyId or privateKey must be provided"})
+		return
+	}
+	if req.Sealed, ok = requestBinary(c, raw, "sealed", req.Sealed, req.Encoding); !ok {
+		return
+	}
+
+	privateKey := req.PrivateKey
+	if req.KeyID != nil {
+		pair, ok := loadKeyPair(c, h.KeyStorage, *req.KeyID, models.KeyOperationDecrypt, models.KeyAlgorithmX25519)
+		if !ok {
+			return
+		}
+		privateKey = pair.PrivateKey
+	}
+
+	plaintext, err := h.X25519Service.Open(privateKey, req.Sealed)
+	if err != nil {
+		if errors.Is(err, processors.ErrAesAuthenticationFailed) || errors.Is(err, processors.ErrUnsupportedSealedBoxCipher) ||
+			errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) || errors.Is(err, processors.ErrInvalidX25519Key) ||
+			errors.Is(err, processors.ErrInvalidSealedBox) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Decryption failed: %s", err)})
+		return
+	}
+
+	respondPlaintext(c, plaintext, req.Encoding)
+}
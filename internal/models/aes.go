@@ -1,6 +1,34 @@
 package models
 
+import "time"
+
 type AesKey struct {
 	Key string `json:"key"`
 	IV  string `json:"iv"`
 }
+
+// AesMode selects the block cipher mode used by the AES service.
+type AesMode string
+
+const (
+	// AesModeGCM is authenticated AES-GCM with a fresh nonce per message (default).
+	AesModeGCM AesMode = "gcm"
+	// AesModeCFB is the legacy unauthenticated CFB mode using the key's fixed IV.
+	AesModeCFB AesMode = "cfb"
+)
+
+// AesKeyStatus is the lifecycle state of a stored AES key.
+type AesKeyStatus string
+
+const (
+	AesKeyStatusActive   AesKeyStatus = "active"
+	AesKeyStatusDisabled AesKeyStatus = "disabled"
+)
+
+// AesKeyMetadata describes a stored AES key without exposing its material.
+type AesKeyMetadata struct {
+	KeyID      string       `json:"keyId" db:"id"`
+	Status     AesKeyStatus `json:"status" db:"status"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	DisabledAt *time.Time   `json:"disabledAt,omitempty" db:"disabled_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// CertificateSubject is the distinguished name of a certificate or CSR.
+type CertificateSubject struct {
+	CommonName         string `json:"commonName"`
+	Organization       string `json:"organization,omitempty"`
+	OrganizationalUnit string `json:"organizationalUnit,omitempty"`
+	Country            string `json:"country,omitempty"`
+	Province           string `json:"province,omitempty"`
+	Locality           string `json:"locality,omitempty"`
+}
+
+// CertificateTemplate describes the CSR or certificate to build for a stored key.
+// Key usages use the RFC 5280 names (digitalSignature, keyEncipherment, ...) and
+// extended key usages the short names serverAuth, clientAuth, codeSigning,
+// emailProtection, timeStamping and ocspSigning.
+type CertificateTemplate struct {
+	Subject        CertificateSubject `json:"subject"`
+	DNSNames       []string           `json:"dnsNames,omitempty"`
+	IPAddresses    []string           `json:"ipAddresses,omitempty"`
+	EmailAddresses []string           `json:"emailAddresses,omitempty"`
+	URIs           []string           `json:"uris,omitempty"`
+	KeyUsage       []string           `json:"keyUsage,omitempty"`
+	ExtKeyUsage    []string           `json:"extKeyUsage,omitempty"`
+	ValidityDays   int                `json:"validityDays,omitempty"`
+	IsCA           bool               `json:"isCA,omitempty"`
+}
+
+// Certificate is an issued X.509 certificate. KeyPairID is nil for certificates
+// issued by an internal CA for a key held outside the service.
+type Certificate struct {
+	ID               int        `json:"id" db:"id"`
+	KeyPairID        *int       `json:"keyPairId,omitempty" db:"key_pair_id"`
+	IssuerCaID       *int       `json:"issuerCaId,omitempty" db:"issuer_ca_id"`
+	SerialNumber     string     `json:"serialNumber" db:"serial_number"`
+	Subject          string     `json:"subject" db:"subject"`
+	Issuer           string     `json:"issuer" db:"issuer"`
+	NotBefore        time.Time  `json:"notBefore" db:"not_before"`
+	NotAfter         time.Time  `json:"notAfter" db:"not_after"`
+	IsCA             bool       `json:"isCA" db:"is_ca"`
+	CertificatePEM   string     `json:"certificate" db:"certificate_pem"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	RevocationReason int        `json:"revocationReason,omitempty" db:"revocation_reason"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// CaPolicy limits what an internal CA signs. DNS names, email domains and URI
+// hosts must equal or be a subdomain of an allowed domain; an empty list
+// allows none of them.
+type CaPolicy struct {
+	AllowedDomains      []string `json:"allowedDomains"`
+	AllowIPAddresses    bool     `json:"allowIpAddresses"`
+	AllowSubordinateCAs bool     `json:"allowSubordinateCAs"`
+	MaxValidityDays     int      `json:"maxValidityDays"`
+}
+
+// CertificateAuthority is a stored key pair and CA certificate that signs CSRs.
+type CertificateAuthority struct {
+	ID             int       `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	KeyPairID      int       `json:"keyPairId" db:"key_pair_id"`
+	CertificateID  int       `json:"certificateId" db:"certificate_id"`
+	ParentID       *int      `json:"parentId,omitempty" db:"parent_id"`
+	Policy         CaPolicy  `json:"policy"`
+	CertificatePEM string    `json:"certificate"`
+	CrlNumber      int64     `json:"-" db:"crl_number"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CsrSigningRequest asks an internal CA to sign a PKCS#10 CSR. The subject and
+// SANs come from the CSR; KeyPairID links the certificate to a stored key whose
+// public key must match the CSR.
+type CsrSigningRequest struct {
+	CSR          string   `json:"csr"`
+	KeyUsage     []string `json:"keyUsage,omitempty"`
+	ExtKeyUsage  []string `json:"extKeyUsage,omitempty"`
+	ValidityDays int      `json:"validityDays,omitempty"`
+	IsCA         bool     `json:"isCA,omitempty"`
+	KeyPairID    *int     `json:"keyPairId,omitempty"`
+}
@@ -10,4 +10,96 @@ type Employee struct {
 	Salary     float64   `db:"salary" json:"salary"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	// Version is incremented on every write and served as the ETag.
+	Version int `db:"version" json:"version"`
 }
+
+// IfMatch is the parsed If-Match precondition of a write. The zero value means the
+// header was absent. Versions may be empty when no listed tag can match, which
+// fails the precondition.
+type IfMatch struct {
+	Present  bool
+	Any      bool
+	Versions []int
+}
+
+// EmployeeListQuery selects one page of employees. Filters are combined with AND;
+// repeated department or position values match any of them. The page starts either
+// at Offset or after the row encoded in Cursor.
+type EmployeeListQuery struct {
+	Departments   []string   `form:"department"`
+	Positions     []string   `form:"position"`
+	SalaryMin     *float64   `form:"salary_min"`
+	SalaryMax     *float64   `form:"salary_max"`
+	CreatedAfter  *time.Time `form:"created_after"`
+	CreatedBefore *time.Time `form:"created_before"`
+	UpdatedAfter  *time.Time `form:"updated_after"`
+	UpdatedBefore *time.Time `form:"updated_before"`
+	// Sort is a comma separated list of fields, each optionally prefixed with '-' for descending order.
+	Sort   string `form:"sort"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	Cursor string `form:"cursor"`
+}
+
+// EmployeeSort is one key of a multi-field sort.
+type EmployeeSort struct {
+	Field      string
+	Descending bool
+}
+
+// EmployeePage is a page of employees with its pagination envelope.
+type EmployeePage struct {
+	Data       []Employee     `json:"data"`
+	Pagination PaginationInfo `json:"pagination"`
+}
+
+// PaginationInfo describes where a page sits in the full result. Offset is only set
+// for offset pagination; NextCursor is set whenever more rows follow and works for
+// both styles.
+type PaginationInfo struct {
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	Total      int    `json:"total"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EmployeeSearchQuery is a full-text search over name, position and department.
+// Every word of Q must match the start of a word in one of those fields.
+type EmployeeSearchQuery struct {
+	Q      string `form:"q"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+// EmployeeSearchResult is a matching employee with its relevance and the fields
+// with matched words wrapped in <mark></mark>. The field text is HTML escaped.
+type EmployeeSearchResult struct {
+	Employee
+	Rank       float64            `db:"rank" json:"rank"`
+	Highlights EmployeeHighlights `db:"highlights" json:"highlights"`
+}
+
+// EmployeeHighlights holds the highlighted snippets of the searched fields.
+type EmployeeHighlights struct {
+	Name       string `db:"name" json:"name"`
+	Position   string `db:"position" json:"position"`
+	Department string `db:"department" json:"department"`
+}
+
+// EmployeeSearchPage is a page of search results, best matches first.
+type EmployeeSearchPage struct {
+	Data       []EmployeeSearchResult `json:"data"`
+	Pagination PaginationInfo         `json:"pagination"`
+}
+
+// PatchType is the media type of a PATCH request body.
+type PatchType string
+
+const (
+	// PatchTypeMerge is an RFC 7386 JSON Merge Patch.
+	PatchTypeMerge PatchType = "application/merge-patch+json"
+	// PatchTypeJSON is an RFC 6902 JSON Patch.
+	PatchTypeJSON PatchType = "application/json-patch+json"
+)
@@ -0,0 +1,17 @@
+package models
+
+// PayloadEncoding names how a binary payload (plaintext, message, associated data)
+// is carried in a JSON string. Binary results and their inputs (ciphertexts,
+// signatures, MACs, sealed boxes) use the same encoding, with base64 instead of UTF-8.
+type PayloadEncoding string
+
+const (
+	// PayloadEncodingUTF8 carries the payload as text (default). Only valid UTF-8 roundtrips.
+	PayloadEncodingUTF8 PayloadEncoding = "utf8"
+	// PayloadEncodingBase64 is standard padded base64 (RFC 4648 section 4).
+	PayloadEncodingBase64 PayloadEncoding = "base64"
+	// PayloadEncodingBase64URL is URL-safe base64 (RFC 4648 section 5), unpadded on output.
+	PayloadEncodingBase64URL PayloadEncoding = "base64url"
+	// PayloadEncodingHex is lowercase hexadecimal on output, either case on input.
+	PayloadEncodingHex PayloadEncoding = "hex"
+)
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// HmacAlgorithm is the hash function a stored HMAC key is bound to.
+type HmacAlgorithm string
+
+const (
+	HmacAlgorithmSHA256 HmacAlgorithm = "HMAC-SHA256"
+	HmacAlgorithmSHA512 HmacAlgorithm = "HMAC-SHA512"
+)
+
+// HmacKey is a base64 encoded HMAC secret and the algorithm it may be used with.
+type HmacKey struct {
+	Key       string        `json:"key"`
+	Algorithm HmacAlgorithm `json:"algorithm"`
+}
+
+// HmacKeyMetadata describes a stored HMAC key without exposing its material.
+// HMAC keys share the active/disabled lifecycle of AES keys.
+type HmacKeyMetadata struct {
+	KeyID      string        `json:"keyId" db:"id"`
+	Algorithm  HmacAlgorithm `json:"algorithm" db:"algorithm"`
+	Status     AesKeyStatus  `json:"status" db:"status"`
+	CreatedAt  time.Time     `json:"createdAt" db:"created_at"`
+	DisabledAt *time.Time    `json:"disabledAt,omitempty" db:"disabled_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// KdfHash is the hash function used by a key derivation function.
+type KdfHash string
+
+const (
+	KdfHashSHA256 KdfHash = "SHA-256"
+	KdfHashSHA384 KdfHash = "SHA-384"
+	KdfHashSHA512 KdfHash = "SHA-512"
+)
+
+// HkdfMode selects the HKDF steps (RFC 5869) to run. The default runs both.
+type HkdfMode string
+
+const (
+	HkdfModeExtractAndExpand HkdfMode = "extract-and-expand"
+	HkdfModeExtract          HkdfMode = "extract"
+	HkdfModeExpand           HkdfMode = "expand"
+)
+
+// DerivedKeyTarget names the algorithm a derived key is meant for and fixes its length.
+type DerivedKeyTarget string
+
+const (
+	DerivedKeyTargetAES128           DerivedKeyTarget = "AES-128"
+	DerivedKeyTargetAES192           DerivedKeyTarget = "AES-192"
+	DerivedKeyTargetAES256           DerivedKeyTarget = "AES-256"
+	DerivedKeyTargetChaCha20Poly1305 DerivedKeyTarget = "ChaCha20-Poly1305"
+	DerivedKeyTargetHMACSHA256       DerivedKeyTarget = "HMAC-SHA256"
+	DerivedKeyTargetHMACSHA512       DerivedKeyTarget = "HMAC-SHA512"
+)
+
+// KdfMasterKeyAlgorithm marks stored secret keys that are only ever used as HKDF
+// input. AES and HMAC working keys are never accepted as master keys: expanding
+// an HMAC key directly would return valid MACs for chosen messages.
+const KdfMasterKeyAlgorithm = "KDF-MASTER"
+
+// KdfMasterKeyMetadata describes a stored KDF master key without its material.
+type KdfMasterKeyMetadata struct {
+	KeyID      string       `json:"keyId" db:"id"`
+	Algorithm  string       `json:"algorithm" db:"algorithm"`
+	Status     AesKeyStatus `json:"status" db:"status"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	DisabledAt *time.Time   `json:"disabledAt,omitempty" db:"disabled_at"`
+}
+
+// SecretKeyMaterial is the raw material of a stored KDF master key, used as
+// input keying material for derivation.
+type SecretKeyMaterial struct {
+	KeyID     string
+	Algorithm string
+	Status    AesKeyStatus
+	Key       []byte
+}
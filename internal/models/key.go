@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// KeyAlgorithm identifies the algorithm of a stored asymmetric key pair.
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRSA       KeyAlgorithm = "RSA"
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ECDSA-P256"
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ECDSA-P384"
+	KeyAlgorithmEd25519   KeyAlgorithm = "Ed25519"
+	KeyAlgorithmX25519    KeyAlgorithm = "X25519"
+)
+
+// SealedBoxCipher selects the AEAD used inside an X25519 sealed box.
+type SealedBoxCipher string
+
+const (
+	SealedBoxAES256GCM        SealedBoxCipher = "AES-256-GCM"
+	SealedBoxChaCha20Poly1305 SealedBoxCipher = "ChaCha20-Poly1305"
+)
+
+// KeyStatus is the lifecycle state of a stored key pair.
+type KeyStatus string
+
+const (
+	// KeyStatusPending keys are stored but not yet usable.
+	KeyStatusPending KeyStatus = "pending"
+	// KeyStatusActive keys can be used for every operation.
+	KeyStatusActive KeyStatus = "active"
+	// KeyStatusRetired keys can only decrypt and verify.
+	KeyStatusRetired KeyStatus = "retired"
+	// KeyStatusRevoked keys are compromised and rejected everywhere.
+	KeyStatusRevoked KeyStatus = "revoked"
+	// KeyStatusDestroyed keys have had their private key erased.
+	KeyStatusDestroyed KeyStatus = "destroyed"
+)
+
+// KeyOperation is a cryptographic use of a stored key pair.
+type KeyOperation string
+
+const (
+	KeyOperationEncrypt KeyOperation = "encrypt"
+	KeyOperationDecrypt KeyOperation = "decrypt"
+	KeyOperationSign    KeyOperation = "sign"
+	KeyOperationVerify  KeyOperation = "verify"
+)
+
+// KeyFormat is an encoding for importing or exporting keys.
+type KeyFormat string
+
+const (
+	KeyFormatPEM KeyFormat = "pem"
+	KeyFormatDER KeyFormat = "der"
+	KeyFormatJWK KeyFormat = "jwk"
+	// KeyFormatOpenSSH is the authorized_keys public key format; export only.
+	KeyFormatOpenSSH KeyFormat = "openssh"
+)
+
+// KeyPair is a stored asymmetric key pair of any algorithm. Keys are PEM
+// encoded: PKIX for public keys, PKCS#1 (RSA) or PKCS#8 for private keys.
+type KeyPair struct {
+	ID         int          `json:"id" db:"id"`
+	Algorithm  KeyAlgorithm `json:"algorithm" db:"algorithm"`
+	KeySize    int          `json:"keySize" db:"key_size"`
+	Status     KeyStatus    `json:"status" db:"status"`
+	NotBefore  *time.Time   `json:"notBefore,omitempty" db:"not_before"`
+	ExpiresAt  *time.Time   `json:"expiresAt,omitempty" db:"expires_at"`
+	ReplacedBy *int         `json:"replacedBy,omitempty" db:"replaced_by"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	PublicKey  string       `json:"publicKey" db:"public_key"`
+	// HasPrivateKey is false for imported public keys and destroyed keys.
+	HasPrivateKey bool   `json:"hasPrivateKey" db:"has_private_key"`
+	PrivateKey    string `json:"-" db:"private_key"`
+}
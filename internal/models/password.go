@@ -0,0 +1,9 @@
+package models
+
+// PasswordAlgorithm is the password hashing scheme of a stored hash.
+type PasswordAlgorithm string
+
+const (
+	PasswordAlgorithmArgon2id PasswordAlgorithm = "argon2id"
+	PasswordAlgorithmBcrypt   PasswordAlgorithm = "bcrypt"
+)
@@ -2,6 +2,47 @@ package models
 
 type RsaKeys struct {
 	ID         int    `json:"-" db:"id"`
+	KeySize    int    `json:"-" db:"key_size"`
 	PublicKey  string `json:"publicKey"`
 	PrivateKey string `json:"privateKey"`
 }
+
+// SignatureScheme selects the RSA signature padding.
+type SignatureScheme string
+
+const (
+	// SignatureSchemePSS is RSASSA-PSS (default).
+	SignatureSchemePSS SignatureScheme = "pss"
+	// SignatureSchemePKCS1v15 is RSASSA-PKCS1-v1_5, kept for legacy verifiers.
+	SignatureSchemePKCS1v15 SignatureScheme = "pkcs1v15"
+)
+
+// HashAlgorithm names the digest used for signing.
+type HashAlgorithm string
+
+const (
+	HashSHA256 HashAlgorithm = "SHA-256"
+	HashSHA384 HashAlgorithm = "SHA-384"
+	HashSHA512 HashAlgorithm = "SHA-512"
+)
+
+// HybridAlgorithm identifies RSA-OAEP (SHA-256) key wrapping of an AES-256-GCM content key.
+const HybridAlgorithm = "RSA-OAEP-256+A256GCM"
+
+// HybridEnvelope is the self-describing JSON form of a hybrid RSA+AES ciphertext.
+// All binary fields are base64 encoded.
+type HybridEnvelope struct {
+	Version      int    `json:"version"`
+	Algorithm    string `json:"alg"`
+	EncryptedKey string `json:"encryptedKey"`
+	Nonce        string `json:"nonce"`
+	CipherText   string `json:"cipherText"`
+}
+
+// EnvelopeFormat selects how a hybrid envelope is serialized.
+type EnvelopeFormat string
+
+const (
+	EnvelopeFormatJSON   EnvelopeFormat = "json"
+	EnvelopeFormatBinary EnvelopeFormat = "binary"
+)
@@ -6,16 +6,32 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"laba6/internal/models" // Adjust the path as needed
 )
 
+// aesGcmEnvelopeVersion is the first byte of every GCM ciphertext envelope:
+// version (1 byte) || nonce (12 bytes) || ciphertext+tag.
+const aesGcmEnvelopeVersion byte = 1
+
+var (
+	// ErrAesAuthenticationFailed is returned when a GCM ciphertext or its associated data was tampered with.
+	ErrAesAuthenticationFailed = errors.New("authentication failed: ciphertext or associated data has been modified")
+	// ErrUnsupportedAesMode is returned for an unknown mode value.
+	ErrUnsupportedAesMode = errors.New("unsupported AES mode")
+	// ErrAesAssociatedDataUnsupported is returned for associated data in a mode without authentication.
+	ErrAesAssociatedDataUnsupported = errors.New("associated data is not supported")
+	// ErrInvalidAesCiphertext is returned for a ciphertext that is not a well-formed envelope.
+	ErrInvalidAesCiphertext = errors.New("invalid ciphertext")
+)
+
 // IAesService defines the interface for AES operations.
 type IAesService interface {
 	GenerateSecretKey() (models.AesKey, error)
-	Encrypt(aesKey models.AesKey, plainText string) (string, error)        // Returns base64 encoded string
-	Decrypt(aesKey models.AesKey, cipherTextBase64 string) (string, error) // cipherText is base64 encoded
+	Encrypt(aesKey models.AesKey, mode models.AesMode, plaintext, associatedData []byte) (string, error)               // Returns base64 encoded string
+	Decrypt(aesKey models.AesKey, mode models.AesMode, cipherTextBase64 string, associatedData []byte) ([]byte, error) // cipherText is base64 encoded
 }
 
 // AesService implements the IAesService interface.
@@ -48,65 +64,152 @@ func (s *AesService) GenerateSecretKey() (models.AesKey, error) {
 	}, nil
 }
 
-// Encrypt encrypts the plainText using the AES key and IV.
-func (s *AesService) Encrypt(aesKey models.AesKey, plainText string) (string, error) {
-	key, err := base64.StdEncoding.DecodeString(aesKey.Key)
+// Encrypt encrypts the plaintext with the given mode. An empty mode defaults to GCM.
+// Associated data is authenticated but not encrypted and is only supported in GCM mode.
+func (s *AesService) Encrypt(aesKey models.AesKey, mode models.AesMode, plaintext, associatedData []byte) (string, error) {
+	switch mode {
+	case models.AesModeGCM, "":
+		return s.encryptGCM(aesKey, plaintext, associatedData)
+	case models.AesModeCFB:
+		if len(associatedData) != 0 {
+			return "", fmt.Errorf("%w in %s mode", ErrAesAssociatedDataUnsupported, mode)
+		}
+		return s.encryptCFB(aesKey, plaintext)
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAesMode, mode)
+	}
+}
+
+// Decrypt decrypts the base64-encoded cipherText with the given mode. An empty mode defaults to GCM.
+func (s *AesService) Decrypt(aesKey models.AesKey, mode models.AesMode, cipherTextBase64 string, associatedData []byte) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(cipherTextBase64)
 	if err != nil {
-		return "", fmt.Errorf("invalid key encoding: %w", err)
+		return nil, fmt.Errorf("%w: failed to decode base64: %v", ErrInvalidAesCiphertext, err)
 	}
-	iv, err := base64.StdEncoding.DecodeString(aesKey.IV)
+
+	switch mode {
+	case models.AesModeGCM, "":
+		return s.decryptGCM(aesKey, ciphertext, associatedData)
+	case models.AesModeCFB:
+		if len(associatedData) != 0 {
+			return nil, fmt.Errorf("%w in %s mode", ErrAesAssociatedDataUnsupported, mode)
+		}
+		return s.decryptCFB(aesKey, ciphertext)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAesMode, mode)
+	}
+}
+
+func (s *AesService) encryptGCM(aesKey models.AesKey, plaintext, associatedData []byte) (string, error) {
+	gcm, err := newGCM(aesKey)
 	if err != nil {
-		return "", fmt.Errorf("invalid IV encoding: %w", err)
+		return "", err
 	}
 
-	plaintext := []byte(plainText)
+	// 1. Fresh random nonce for every message
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
 
-	// 1. Create a new AES cipher block
-	block, err := aes.NewCipher(key)
+	// 2. Build the envelope: version || nonce || ciphertext+tag
+	envelope := make([]byte, 0, 1+len(nonce)+len(plaintext)+gcm.Overhead())
+	envelope = append(envelope, aesGcmEnvelopeVersion)
+	envelope = append(envelope, nonce...)
+	envelope = gcm.Seal(envelope, nonce, plaintext, associatedData)
+
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+func (s *AesService) decryptGCM(aesKey models.AesKey, envelope, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(aesKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, err
 	}
 
-	// 2. Create a CFB Encrypter
-	stream := cipher.NewCFBEncrypter(block, iv)
+	if len(envelope) < 1+gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext is too short", ErrInvalidAesCiphertext)
+	}
+	if envelope[0] != aesGcmEnvelopeVersion {
+		return nil, fmt.Errorf("%w: unsupported envelope version %d", ErrInvalidAesCiphertext, envelope[0])
+	}
+
+	nonce := envelope[1 : 1+gcm.NonceSize()]
+	sealed := envelope[1+gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, sealed, associatedData)
+	if err != nil {
+		return nil, ErrAesAuthenticationFailed
+	}
+	return plaintext, nil
+}
+
+func (s *AesService) encryptCFB(aesKey models.AesKey, plaintext []byte) (string, error) {
+	block, iv, err := newBlockWithIV(aesKey)
+	if err != nil {
+		return "", err
+	}
 
-	// 3. Encrypt the data
+	// Create a CFB Encrypter and encrypt the data
+	stream := cipher.NewCFBEncrypter(block, iv)
 	ciphertext := make([]byte, len(plaintext))
 	stream.XORKeyStream(ciphertext, plaintext)
 
-	// 4. Return Base64 encoded ciphertext
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt decrypts the base64-encoded cipherText using the AES key and IV.
-func (s *AesService) Decrypt(aesKey models.AesKey, cipherTextBase64 string) (string, error) {
-	key, err := base64.StdEncoding.DecodeString(aesKey.Key)
+func (s *AesService) decryptCFB(aesKey models.AesKey, ciphertext []byte) ([]byte, error) {
+	block, iv, err := newBlockWithIV(aesKey)
 	if err != nil {
-		return "", fmt.Errorf("invalid key encoding: %w", err)
+		return nil, err
 	}
-	iv, err := base64.StdEncoding.DecodeString(aesKey.IV)
+
+	// Create a CFB Decrypter (must match the mode used for encryption)
+	stream := cipher.NewCFBDecrypter(block, iv)
+	plaintext := make([]byte, len(ciphertext))
+	stream.XORKeyStream(plaintext, ciphertext)
+
+	return plaintext, nil
+}
+
+func newBlock(aesKey models.AesKey) (cipher.Block, error) {
+	key, err := base64.StdEncoding.DecodeString(aesKey.Key)
 	if err != nil {
-		return "", fmt.Errorf("invalid IV encoding: %w", err)
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
 	}
 
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherTextBase64)
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 ciphertext: %w", err)
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
 	}
+	return block, nil
+}
 
-	// 1. Create a new AES cipher block
-	block, err := aes.NewCipher(key)
+func newGCM(aesKey models.AesKey) (cipher.AEAD, error) {
+	block, err := newBlock(aesKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, err
 	}
 
-	// 2. Create a CFB Decrypter (must match the mode used for encryption)
-	stream := cipher.NewCFBDecrypter(block, iv)
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
 
-	// 3. Decrypt the data
-	plaintext := make([]byte, len(ciphertext))
-	stream.XORKeyStream(plaintext, ciphertext)
+func newBlockWithIV(aesKey models.AesKey) (cipher.Block, []byte, error) {
+	block, err := newBlock(aesKey)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// 4. Return plaintext string
-	return string(plaintext), nil
+	iv, err := base64.StdEncoding.DecodeString(aesKey.IV)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid IV encoding: %w", err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, nil, fmt.Errorf("invalid IV length %d, expected %d", len(iv), aes.BlockSize)
+	}
+	return block, iv, nil
 }
@@ -1,6 +1,9 @@
 package processors_test
 
 import (
+	"encoding/base64"
+	"errors"
+	"laba6/internal/models"
 	"laba6/internal/processors"
 	"testing"
 )
@@ -37,7 +40,7 @@ func TestAesService_EncryptDecrypt(t *testing.T) {
 		t.Fatalf("Setup failed: Could not generate keys: %v", err)
 	}
 
-	ciphertext, err := aesService.Encrypt(aesKey, originalMessage)
+	ciphertext, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte(originalMessage), nil)
 	if err != nil {
 		t.Fatalf("Encrypt failed: %v", err)
 	}
@@ -46,12 +49,12 @@ func TestAesService_EncryptDecrypt(t *testing.T) {
 		t.Fatal("Ciphertext is empty, encryption failed.")
 	}
 
-	decryptedMessage, err := aesService.Decrypt(aesKey, ciphertext)
+	decryptedMessage, err := aesService.Decrypt(aesKey, models.AesModeGCM, ciphertext, nil)
 	if err != nil {
 		t.Fatalf("Decrypt failed: %v", err)
 	}
 
-	if decryptedMessage != originalMessage {
+	if string(decryptedMessage) != originalMessage {
 		t.Errorf("Decryption mismatch:\nExpected: %s\nActual: %s", originalMessage, decryptedMessage)
 	}
 }
@@ -69,7 +72,7 @@ func TestAesService_Decrypt_InvalidKey(t *testing.T) {
 		t.Fatalf("Setup failed: Could not generate second keyset: %v", err)
 	}
 
-	ciphertext, err := aesService.Encrypt(keys1, originalMessage)
+	ciphertext, err := aesService.Encrypt(keys1, models.AesModeGCM, []byte(originalMessage), nil)
 	if err != nil {
 		t.Fatalf("Encrypt failed: %v", err)
 	}
@@ -77,15 +80,144 @@ func TestAesService_Decrypt_InvalidKey(t *testing.T) {
 	badKeySet := keys2
 	badKeySet.IV = keys1.IV
 
-	_, err = aesService.Decrypt(badKeySet, ciphertext)
+	_, err = aesService.Decrypt(badKeySet, models.AesModeGCM, ciphertext, nil)
 
 	if err == nil {
 		invalidFormatKey := keys1
 		invalidFormatKey.Key = "This-is-not-base64-key"
-		_, err = aesService.Decrypt(invalidFormatKey, ciphertext)
+		_, err = aesService.Decrypt(invalidFormatKey, models.AesModeGCM, ciphertext, nil)
 
 		if err == nil {
 			t.Error("Decrypt should have failed due to invalid key format, but it succeeded.")
 		}
 	}
 }
+
+func TestAesService_GCM_UniqueNonces(t *testing.T) {
+	const originalMessage = "Same message twice"
+
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	first, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte(originalMessage), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	second, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte(originalMessage), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	if first == second {
+		t.Error("Two encryptions of the same message produced identical ciphertexts, nonce is being reused.")
+	}
+}
+
+func TestAesService_GCM_AssociatedData(t *testing.T) {
+	const originalMessage = "Message bound to a context"
+	const associatedData = "employee:42"
+
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	ciphertext, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte(originalMessage), []byte(associatedData))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	decryptedMessage, err := aesService.Decrypt(aesKey, models.AesModeGCM, ciphertext, []byte(associatedData))
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(decryptedMessage) != originalMessage {
+		t.Errorf("Decryption mismatch:\nExpected: %s\nActual: %s", originalMessage, decryptedMessage)
+	}
+
+	_, err = aesService.Decrypt(aesKey, models.AesModeGCM, ciphertext, []byte("employee:43"))
+	if !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected authentication failure for wrong associated data, got: %v", err)
+	}
+}
+
+func TestAesService_GCM_TamperedCiphertext(t *testing.T) {
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	ciphertext, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte("Do not touch"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		t.Fatalf("Ciphertext is not valid base64: %v", err)
+	}
+	raw[len(raw)-1] ^= 0x01
+	tampered := base64.StdEncoding.EncodeToString(raw)
+
+	_, err = aesService.Decrypt(aesKey, models.AesModeGCM, tampered, nil)
+	if !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected authentication failure for tampered ciphertext, got: %v", err)
+	}
+}
+
+func TestAesService_CFB_Legacy(t *testing.T) {
+	const originalMessage = "Legacy CFB payload"
+
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	ciphertext, err := aesService.Encrypt(aesKey, models.AesModeCFB, []byte(originalMessage), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	decryptedMessage, err := aesService.Decrypt(aesKey, models.AesModeCFB, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(decryptedMessage) != originalMessage {
+		t.Errorf("Decryption mismatch:\nExpected: %s\nActual: %s", originalMessage, decryptedMessage)
+	}
+
+	if _, err := aesService.Encrypt(aesKey, "ecb", []byte(originalMessage), nil); !errors.Is(err, processors.ErrUnsupportedAesMode) {
+		t.Errorf("Expected unsupported mode error, got: %v", err)
+	}
+}
+
+func TestAesService_MalformedInput(t *testing.T) {
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	if _, err := aesService.Encrypt(aesKey, models.AesModeCFB, []byte("data"), []byte("context")); !errors.Is(err, processors.ErrAesAssociatedDataUnsupported) {
+		t.Errorf("Expected ErrAesAssociatedDataUnsupported for CFB with associated data, got: %v", err)
+	}
+
+	ciphertext, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte("data"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(ciphertext)
+	raw[0] = 2
+
+	ciphertexts := map[string]string{
+		"not base64":       "not base64!",
+		"too short":        base64.StdEncoding.EncodeToString(raw[:10]),
+		"unknown envelope": base64.StdEncoding.EncodeToString(raw),
+	}
+	for name, ciphertext := range ciphertexts {
+		if _, err := aesService.Decrypt(aesKey, models.AesModeGCM, ciphertext, nil); !errors.Is(err, processors.ErrInvalidAesCiphertext) {
+			t.Errorf("%s: expected ErrInvalidAesCiphertext, got: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package processors
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"laba6/internal/models"
+	"math"
+)
+
+// Streaming AES-GCM using the STREAM construction (Hoang, Reyhanitabar, Rogaway,
+// Vizár 2015), laid out like Tink's AES-GCM-HKDF streaming AEAD:
+//
+//	header:  version (1) || segment size (4, big endian) || salt (16) || nonce prefix (7)
+//	segment: AES-GCM(subkey, nonce prefix || segment index (4) || last flag (1), plaintext, header)
+//
+// Every stream gets its own subkey, HKDF-SHA256(key, salt), so random nonce
+// prefixes cannot collide across streams. The last flag makes truncation and
+// appending detectable; authenticating the header binds the segment size.
+const (
+	aesStreamVersion    byte = 1
+	aesStreamSaltSize        = 16
+	aesStreamPrefixSize      = 7
+	aesStreamHeaderSize      = 1 + 4 + aesStreamSaltSize + aesStreamPrefixSize
+	aesStreamInfo            = "laba6 AES-GCM STREAM v1"
+
+	// DefaultStreamSegmentSize is the plaintext size of every segment but the last.
+	DefaultStreamSegmentSize = 64 * 1024
+	// Bounds on the segment size read from an untrusted header.
+	minStreamSegmentSize = 4 * 1024
+	maxStreamSegmentSize = 1024 * 1024
+)
+
+// ErrInvalidStream is returned for a ciphertext stream with a malformed or truncated header.
+var ErrInvalidStream = errors.New("invalid encrypted stream")
+
+// IAesStreamService encrypts and decrypts arbitrarily large streams in constant memory.
+type IAesStreamService interface {
+	EncryptStream(aesKey models.AesKey, dst io.Writer, src io.Reader) error
+	DecryptStream(aesKey models.AesKey, dst io.Writer, src io.Reader) error
+}
+
+type AesStreamService struct {
+	segmentSize int
+}
+
+func NewAesStreamService(segmentSize int) *AesStreamService {
+	return &AesStreamService{segmentSize: segmentSize}
+}
+
+// EncryptStream writes the header followed by the encrypted segments of src.
+// An empty input produces a single empty final segment.
+func (s *AesStreamService) EncryptStream(aesKey models.AesKey, dst io.Writer, src io.Reader) error {
+	header := make([]byte, aesStreamHeaderSize)
+	header[0] = aesStreamVersion
+	binary.BigEndian.PutUint32(header[1:5], uint32(s.segmentSize))
+	if _, err := io.ReadFull(rand.Reader, header[5:]); err != nil {
+		return fmt.Errorf("failed to generate stream salt: %w", err)
+	}
+
+	gcm, err := newStreamGCM(aesKey, header)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(src)
+	plaintext := make([]byte, s.segmentSize)
+	sealed := make([]byte, 0, s.segmentSize+gcm.Overhead())
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(reader, plaintext)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return fmt.Errorf("failed to read plaintext: %w", err)
+		}
+		if !last {
+			last = atEOF(reader)
+		}
+		if !last && index == math.MaxUint32 {
+			return fmt.Errorf("%w: too many segments", ErrInvalidStream)
+		}
+
+		sealed = gcm.Seal(sealed[:0], streamNonce(header, index, last), plaintext[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream authenticates and writes src segment by segment. A segment is only
+// written after it verified, but earlier segments are already written when a later
+// one fails, so callers must discard the output on error.
+func (s *AesStreamService) DecryptStream(aesKey models.AesKey, dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+	header := make([]byte, aesStreamHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return fmt.Errorf("%w: missing header", ErrInvalidStream)
+	}
+	if header[0] != aesStreamVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidStream, header[0])
+	}
+	segmentSize := int(binary.BigEndian.Uint32(header[1:5]))
+	if segmentSize < minStreamSegmentSize || segmentSize > maxStreamSegmentSize {
+		return fmt.Errorf("%w: segment size %d is out of range", ErrInvalidStream, segmentSize)
+	}
+
+	gcm, err := newStreamGCM(aesKey, header)
+	if err != nil {
+		return err
+	}
+
+	sealed := make([]byte, segmentSize+gcm.Overhead())
+	plaintext := make([]byte, 0, segmentSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(reader, sealed)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return fmt.Errorf("failed to read ciphertext: %w", err)
+		}
+		if !last {
+			last = atEOF(reader)
+		}
+		if n < gcm.Overhead() {
+			return fmt.Errorf("%w: truncated segment", ErrAesAuthenticationFailed)
+		}
+
+		plaintext, err = gcm.Open(plaintext[:0], streamNonce(header, index, last), sealed[:n], header)
+		if err != nil {
+			return ErrAesAuthenticationFailed
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if index == math.MaxUint32 {
+			return fmt.Errorf("%w: too many segments", ErrInvalidStream)
+		}
+	}
+}
+
+// newStreamGCM derives the per-stream subkey from the key and the header salt.
+func newStreamGCM(aesKey models.AesKey, header []byte) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(aesKey.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	subkey, err := hkdf.Key(sha256.New, key, header[5:5+aesStreamSaltSize], aesStreamInfo, len(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive stream key: %w", err)
+	}
+
+	block, err := aes.NewCipher(subkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+func streamNonce(header []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, aesStreamPrefixSize+5)
+	nonce = append(nonce, header[aesStreamHeaderSize-aesStreamPrefixSize:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// atEOF reports whether the reader has no more data, without consuming any.
+func atEOF(reader *bufio.Reader) bool {
+	_, err := reader.Peek(1)
+	return err == io.EOF
+}
@@ -0,0 +1,76 @@
+package processors_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var streamService = processors.NewAesStreamService(processors.DefaultStreamSegmentSize)
+
+func TestAesStreamService_RoundTrip(t *testing.T) {
+	key, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate key: %v", err)
+	}
+
+	sizes := []int{0, 1, processors.DefaultStreamSegmentSize, processors.DefaultStreamSegmentSize + 1, 3*processors.DefaultStreamSegmentSize + 17}
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		var encrypted bytes.Buffer
+		if err := streamService.EncryptStream(key, &encrypted, bytes.NewReader(plaintext)); err != nil {
+			t.Fatalf("EncryptStream(%d bytes) failed: %v", size, err)
+		}
+
+		var decrypted bytes.Buffer
+		if err := streamService.DecryptStream(key, &decrypted, bytes.NewReader(encrypted.Bytes())); err != nil {
+			t.Fatalf("DecryptStream(%d bytes) failed: %v", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Errorf("Round trip of %d bytes did not reproduce the plaintext", size)
+		}
+	}
+}
+
+func TestAesStreamService_DetectsTampering(t *testing.T) {
+	key, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate key: %v", err)
+	}
+	plaintext := make([]byte, 2*processors.DefaultStreamSegmentSize+100)
+	var encrypted bytes.Buffer
+	if err := streamService.EncryptStream(key, &encrypted, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("EncryptStream failed: %v", err)
+	}
+	ciphertext := encrypted.Bytes()
+	segment := processors.DefaultStreamSegmentSize + 16
+	header := len(ciphertext) - 2*segment - (100 + 16)
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[header+segment+5] ^= 0x01
+
+	cases := map[string][]byte{
+		"flipped bit":          flipped,
+		"truncated at segment": ciphertext[:header+2*segment],
+		"dropped last byte":    ciphertext[:len(ciphertext)-1],
+		"appended data":        append(bytes.Clone(ciphertext), 0),
+	}
+	for name, data := range cases {
+		err := streamService.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(data))
+		if !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+			t.Errorf("%s: expected ErrAesAuthenticationFailed, got: %v", name, err)
+		}
+	}
+
+	otherKey, _ := aesService.GenerateSecretKey()
+	if err := streamService.DecryptStream(otherKey, &bytes.Buffer{}, bytes.NewReader(ciphertext)); !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected ErrAesAuthenticationFailed with the wrong key, got: %v", err)
+	}
+	if err := streamService.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(ciphertext[:10])); !errors.Is(err, processors.ErrInvalidStream) {
+		t.Errorf("Expected ErrInvalidStream for a truncated header, got: %v", err)
+	}
+}
@@ -0,0 +1,365 @@
+package processors
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// CrlValidity is how long a published CRL stays current.
+const CrlValidity = 7 * 24 * time.Hour
+
+// crlRenewBefore is how long before its next update a published CRL is reissued.
+const crlRenewBefore = 24 * time.Hour
+
+// maxCommonNameLength is the RFC 5280 upper bound of a common name.
+const maxCommonNameLength = 64
+
+// ErrCaPolicyViolation is returned when a CSR asks for something the CA policy forbids.
+var ErrCaPolicyViolation = errors.New("certificate authority policy violation")
+
+// RevocationReasons maps RFC 5280 reason names to their CRL reason codes.
+var RevocationReasons = map[string]int{
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"cACompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+	"privilegeWithdrawn":   9,
+}
+
+// ICaService signs CSRs and CRLs with a stored CA key.
+type ICaService interface {
+	CheckCaCertificate(certificatePEM, publicKeyPEM string) error
+	CheckCertificateKey(certificatePEM, publicKeyPEM string) error
+	CheckSubordinatePolicy(parent, child models.CaPolicy) error
+	SignCSR(ca models.CertificateAuthority, caPrivateKeyPEM string, request models.CsrSigningRequest) (models.Certificate, error)
+	CreateCRL(ca models.CertificateAuthority, caPrivateKeyPEM string, revoked []models.Certificate, number int64) ([]byte, error)
+	CrlIsCurrent(crl []byte, revoked []models.Certificate, now time.Time) bool
+}
+
+type CaService struct{}
+
+func NewCaService() *CaService {
+	return &CaService{}
+}
+
+// CheckCaCertificate verifies that a certificate may act as a CA and belongs to the given key.
+func (s *CaService) CheckCaCertificate(certificatePEM, publicKeyPEM string) error {
+	cert, err := parseCertificate(certificatePEM)
+	if err != nil {
+		return err
+	}
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return fmt.Errorf("%w: the certificate is not a CA certificate", ErrInvalidCertificateRequest)
+	}
+	required := x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	if cert.KeyUsage&required != required {
+		return fmt.Errorf("%w: a CA certificate needs the keyCertSign and cRLSign key usages", ErrInvalidCertificateRequest)
+	}
+	return certificateKeyMatches(cert, publicKeyPEM)
+}
+
+// CheckCertificateKey verifies that a certificate was issued for the given public key.
+func (s *CaService) CheckCertificateKey(certificatePEM, publicKeyPEM string) error {
+	cert, err := parseCertificate(certificatePEM)
+	if err != nil {
+		return err
+	}
+	return certificateKeyMatches(cert, publicKeyPEM)
+}
+
+// CheckSubordinatePolicy rejects a subordinate CA policy that grants more than the
+// policy of its parent: every allowed domain must be allowed by the parent, and
+// IP addresses, validity and further subordinates may not exceed the parent's.
+func (s *CaService) CheckSubordinatePolicy(parent, child models.CaPolicy) error {
+	for _, domain := range child.AllowedDomains {
+		if !domainAllowed(domain, parent.AllowedDomains) {
+			return fmt.Errorf("%w: domain %q is not allowed by the parent CA", ErrCaPolicyViolation, domain)
+		}
+	}
+	if child.AllowIPAddresses && !parent.AllowIPAddresses {
+		return fmt.Errorf("%w: the parent CA does not allow IP addresses", ErrCaPolicyViolation)
+	}
+	if child.AllowSubordinateCAs {
+		return fmt.Errorf("%w: subordinate CAs are issued with a path length of zero and cannot issue CAs", ErrCaPolicyViolation)
+	}
+	if child.MaxValidityDays > parent.MaxValidityDays {
+		return fmt.Errorf("%w: maxValidityDays may not exceed the parent's %d", ErrCaPolicyViolation, parent.MaxValidityDays)
+	}
+	return nil
+}
+
+// SignCSR issues a certificate for a PKCS#10 CSR after checking its signature
+// and the CA policy. Only the checked common name of the CSR subject is copied.
+// Subordinate CAs are issued with a path length of zero and
+// critical name constraints from the issuer policy, so they cannot issue
+// certificates the issuer itself would refuse.
+func (s *CaService) SignCSR(ca models.CertificateAuthority, caPrivateKeyPEM string, request models.CsrSigningRequest) (models.Certificate, error) {
+	csr, err := parseCSR(request.CSR)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+	if err := checkCaPolicy(ca.Policy, csr, request); err != nil {
+		return models.Certificate{}, err
+	}
+
+	caCert, err := parseCertificate(ca.CertificatePEM)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+	signer, err := parseSigner(caPrivateKeyPEM)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+
+	validityDays := request.ValidityDays
+	if validityDays == 0 {
+		validityDays = min(DefaultCertificateValidityDays, ca.Policy.MaxValidityDays)
+	}
+	template := models.CertificateTemplate{
+		Subject:        models.CertificateSubject{CommonName: csr.Subject.CommonName},
+		DNSNames:       csr.DNSNames,
+		EmailAddresses: csr.EmailAddresses,
+		KeyUsage:       request.KeyUsage,
+		ExtKeyUsage:    request.ExtKeyUsage,
+		ValidityDays:   validityDays,
+		IsCA:           request.IsCA,
+	}
+	for _, ip := range csr.IPAddresses {
+		template.IPAddresses = append(template.IPAddresses, ip.String())
+	}
+	for _, uri := range csr.URIs {
+		template.URIs = append(template.URIs, uri.String())
+	}
+
+	cert, err := buildCertificateTemplate(template, csr.PublicKey)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+	cert.MaxPathLenZero = request.IsCA
+	if request.IsCA {
+		applyNameConstraints(cert, ca.Policy)
+	}
+	if cert.NotAfter.After(caCert.NotAfter) {
+		return models.Certificate{}, fmt.Errorf("%w: the certificate would outlive the CA certificate, which expires at %s",
+			ErrCaPolicyViolation, caCert.NotAfter.Format(time.RFC3339))
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, cert, caCert, csr.PublicKey, signer)
+	if err != nil {
+		return models.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return certificateFromDER(der)
+}
+
+// CreateCRL builds a DER encoded CRL listing the revoked certificates of a CA.
+func (s *CaService) CreateCRL(ca models.CertificateAuthority, caPrivateKeyPEM string, revoked []models.Certificate, number int64) ([]byte, error) {
+	caCert, err := parseCertificate(ca.CertificatePEM)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parseSigner(caPrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, cert := range revoked {
+		if cert.RevokedAt == nil {
+			continue
+		}
+		serial, ok := new(big.Int).SetString(cert.SerialNumber, 16)
+		if !ok {
+			return nil, fmt.Errorf("certificate %d has an invalid serial number %q", cert.ID, cert.SerialNumber)
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: *cert.RevokedAt,
+			ReasonCode:     cert.RevocationReason,
+		})
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(CrlValidity),
+		RevokedCertificateEntries: entries,
+	}, caCert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRL: %w", err)
+	}
+	return crl, nil
+}
+
+// CrlIsCurrent reports whether a published CRL can still be served: it lists every
+// revoked certificate and is not about to reach its next update.
+func (s *CaService) CrlIsCurrent(crl []byte, revoked []models.Certificate, now time.Time) bool {
+	if len(crl) == 0 {
+		return false
+	}
+	parsed, err := x509.ParseRevocationList(crl)
+	if err != nil || now.Add(crlRenewBefore).After(parsed.NextUpdate) {
+		return false
+	}
+
+	listed := make(map[string]bool, len(parsed.RevokedCertificateEntries))
+	for _, entry := range parsed.RevokedCertificateEntries {
+		listed[entry.SerialNumber.Text(16)] = true
+	}
+	for _, cert := range revoked {
+		serial, ok := new(big.Int).SetString(cert.SerialNumber, 16)
+		if cert.RevokedAt != nil && (!ok || !listed[serial.Text(16)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCaPolicy rejects SANs outside the allowed domains and requests beyond the CA limits.
+func checkCaPolicy(policy models.CaPolicy, csr *x509.CertificateRequest, request models.CsrSigningRequest) error {
+	if request.ValidityDays > policy.MaxValidityDays {
+		return fmt.Errorf("%w: validityDays may not exceed %d", ErrCaPolicyViolation, policy.MaxValidityDays)
+	}
+	if request.IsCA && !policy.AllowSubordinateCAs {
+		return fmt.Errorf("%w: the CA does not issue subordinate CA certificates", ErrCaPolicyViolation)
+	}
+	if len(csr.IPAddresses) > 0 && !policy.AllowIPAddresses {
+		return fmt.Errorf("%w: IP address SANs are not allowed", ErrCaPolicyViolation)
+	}
+
+	// Clients that still match host names against the CN must not see names the
+	// SANs could not carry. A CA's CN is a display name and only bounded in length.
+	commonName := csr.Subject.CommonName
+	if len(commonName) > maxCommonNameLength {
+		return fmt.Errorf("%w: the common name may be at most %d characters", ErrCaPolicyViolation, maxCommonNameLength)
+	}
+	if commonName != "" && !request.IsCA && (!validDNSName(commonName) || !domainAllowed(commonName, policy.AllowedDomains)) {
+		return fmt.Errorf("%w: common name %q is not an allowed DNS name", ErrCaPolicyViolation, commonName)
+	}
+
+	for _, name := range csr.DNSNames {
+		if !domainAllowed(name, policy.AllowedDomains) {
+			return fmt.Errorf("%w: DNS name %q is not allowed", ErrCaPolicyViolation, name)
+		}
+	}
+	for _, email := range csr.EmailAddresses {
+		_, domain, _ := strings.Cut(email, "@")
+		if !domainAllowed(domain, policy.AllowedDomains) {
+			return fmt.Errorf("%w: email address %q is not allowed", ErrCaPolicyViolation, email)
+		}
+	}
+	for _, uri := range csr.URIs {
+		if !domainAllowed(uri.Hostname(), policy.AllowedDomains) {
+			return fmt.Errorf("%w: URI %q is not allowed", ErrCaPolicyViolation, uri)
+		}
+	}
+	return nil
+}
+
+// applyNameConstraints limits a subordinate CA certificate to the names the policy
+// allows. An empty domain list permits only the reserved "invalid" TLD (RFC 6761),
+// so no real name is allowed; without AllowIPAddresses all IP addresses are excluded.
+func applyNameConstraints(cert *x509.Certificate, policy models.CaPolicy) {
+	cert.PermittedDNSDomainsCritical = true
+	domains := policy.AllowedDomains
+	if len(domains) == 0 {
+		domains = []string{"invalid"}
+	}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(domain, "."))
+		cert.PermittedDNSDomains = append(cert.PermittedDNSDomains, domain)
+		// Email and URI constraints match the host exactly; a leading dot matches subdomains.
+		cert.PermittedEmailAddresses = append(cert.PermittedEmailAddresses, domain, "."+domain)
+		cert.PermittedURIDomains = append(cert.PermittedURIDomains, domain, "."+domain)
+	}
+	if !policy.AllowIPAddresses {
+		cert.ExcludedIPRanges = []*net.IPNet{
+			{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+			{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+		}
+	}
+}
+
+// validDNSName reports whether name is a host name of letters, digits and hyphens,
+// optionally with a leading wildcard label.
+func validDNSName(name string) bool {
+	name = strings.TrimPrefix(strings.TrimSuffix(name, "."), "*.")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// domainAllowed reports whether name equals or is a subdomain of an allowed domain.
+func domainAllowed(name string, allowed []string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return false
+	}
+	for _, domain := range allowed {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func certificateKeyMatches(cert *x509.Certificate, publicKeyPEM string) error {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return fmt.Errorf("failed to decode public key PEM block")
+	}
+	if !bytes.Equal(cert.RawSubjectPublicKeyInfo, block.Bytes) {
+		return fmt.Errorf("%w: the certificate does not belong to the key pair", ErrInvalidCertificateRequest)
+	}
+	return nil
+}
+
+func parseCSR(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || (block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST") {
+		return nil, fmt.Errorf("%w: csr must be a PEM encoded CERTIFICATE REQUEST", ErrInvalidCertificateRequest)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificateRequest, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: CSR signature is invalid: %v", ErrInvalidCertificateRequest, err)
+	}
+	return csr, nil
+}
+
+func parseCertificate(certificatePEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode certificate PEM block")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,293 @@
+package processors_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var caService = processors.NewCaService()
+
+// newTestCA creates a self-signed root CA restricted to *.test.internal.
+func newTestCA(t *testing.T) (models.CertificateAuthority, models.KeyPair) {
+	t.Helper()
+	key, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate CA keys: %v", err)
+	}
+	root, err := certificateService.SelfSign(key.PrivateKey, models.CertificateTemplate{
+		Subject: models.CertificateSubject{CommonName: "Test Root CA"},
+		IsCA:    true,
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: Could not create CA certificate: %v", err)
+	}
+	if err := caService.CheckCaCertificate(root.CertificatePEM, key.PublicKey); err != nil {
+		t.Fatalf("CheckCaCertificate rejected a CA certificate: %v", err)
+	}
+
+	return models.CertificateAuthority{
+		ID:             1,
+		CertificatePEM: root.CertificatePEM,
+		Policy:         models.CaPolicy{AllowedDomains: []string{"test.internal"}, MaxValidityDays: 90},
+	}, key
+}
+
+func newTestCSR(t *testing.T, template models.CertificateTemplate) string {
+	t.Helper()
+	key, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate leaf keys: %v", err)
+	}
+	csr, err := certificateService.CreateCSR(key.PrivateKey, template)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not create CSR: %v", err)
+	}
+	return csr
+}
+
+func TestCaService_SignCSR(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	csr := newTestCSR(t, models.CertificateTemplate{
+		Subject:  models.CertificateSubject{CommonName: "svc.test.internal", Organization: "Laba6"},
+		DNSNames: []string{"svc.test.internal"},
+	})
+
+	cert, err := caService.SignCSR(ca, caKey.PrivateKey, models.CsrSigningRequest{CSR: csr, ExtKeyUsage: []string{"clientAuth"}})
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(ca.CertificatePEM))
+	block, _ := pem.Decode([]byte(cert.CertificatePEM))
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Issued certificate does not parse: %v", err)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("Issued certificate does not chain to the CA: %v", err)
+	}
+	// Only the checked common name of the CSR subject is copied.
+	if cert.Subject != "CN=svc.test.internal" || cert.Issuer != "CN=Test Root CA" {
+		t.Errorf("Unexpected subject/issuer: %s / %s", cert.Subject, cert.Issuer)
+	}
+	if days := cert.NotAfter.Sub(cert.NotBefore).Hours() / 24; days != 90 {
+		t.Errorf("Expected the policy maximum of 90 days, got %v", days)
+	}
+}
+
+func TestCaService_SignCSRPolicy(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	allowed := newTestCSR(t, models.CertificateTemplate{
+		Subject:  models.CertificateSubject{CommonName: "svc.test.internal"},
+		DNSNames: []string{"svc.test.internal"},
+	})
+
+	cases := map[string]models.CsrSigningRequest{
+		"foreign DNS name": {CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:  models.CertificateSubject{CommonName: "svc.test.internal"},
+			DNSNames: []string{"svc.test.internal.evil.com"},
+		})},
+		"foreign common name": {CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:  models.CertificateSubject{CommonName: "www.evil.com"},
+			DNSNames: []string{"svc.test.internal"},
+		})},
+		"common name not a DNS name": {CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:  models.CertificateSubject{CommonName: "svc test.internal"},
+			DNSNames: []string{"svc.test.internal"},
+		})},
+		"IP address": {CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:     models.CertificateSubject{CommonName: "svc.test.internal"},
+			IPAddresses: []string{"10.0.0.1"},
+		})},
+		"validity": {CSR: allowed, ValidityDays: 91},
+		"sub CA":   {CSR: allowed, IsCA: true},
+	}
+	for name, request := range cases {
+		if _, err := caService.SignCSR(ca, caKey.PrivateKey, request); !errors.Is(err, processors.ErrCaPolicyViolation) {
+			t.Errorf("%s: expected ErrCaPolicyViolation, got: %v", name, err)
+		}
+	}
+
+	tampered := []byte(allowed)
+	tampered[len(tampered)/2] ^= 0x01
+	if _, err := caService.SignCSR(ca, caKey.PrivateKey, models.CsrSigningRequest{CSR: string(tampered)}); !errors.Is(err, processors.ErrInvalidCertificateRequest) {
+		t.Errorf("Expected ErrInvalidCertificateRequest for a tampered CSR, got: %v", err)
+	}
+}
+
+func TestCaService_CreateCRL(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	cert, err := caService.SignCSR(ca, caKey.PrivateKey, models.CsrSigningRequest{CSR: newTestCSR(t, models.CertificateTemplate{
+		Subject:  models.CertificateSubject{CommonName: "svc.test.internal"},
+		DNSNames: []string{"svc.test.internal"},
+	})})
+	if err != nil {
+		t.Fatalf("Setup failed: SignCSR failed: %v", err)
+	}
+	revokedAt := time.Now().UTC().Truncate(time.Second)
+	cert.RevokedAt = &revokedAt
+	cert.RevocationReason = processors.RevocationReasons["keyCompromise"]
+
+	der, err := caService.CreateCRL(ca, caKey.PrivateKey, []models.Certificate{cert}, 7)
+	if err != nil {
+		t.Fatalf("CreateCRL failed: %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("CRL does not parse: %v", err)
+	}
+	block, _ := pem.Decode([]byte(ca.CertificatePEM))
+	issuer, _ := x509.ParseCertificate(block.Bytes)
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		t.Errorf("CRL is not signed by the CA: %v", err)
+	}
+	if crl.Number.Int64() != 7 || len(crl.RevokedCertificateEntries) != 1 {
+		t.Fatalf("Unexpected CRL number or entries: %v / %d", crl.Number, len(crl.RevokedCertificateEntries))
+	}
+	entry := crl.RevokedCertificateEntries[0]
+	serial, _ := new(big.Int).SetString(cert.SerialNumber, 16)
+	if entry.SerialNumber.Cmp(serial) != 0 || entry.ReasonCode != 1 {
+		t.Errorf("Unexpected CRL entry: serial %x reason %d", entry.SerialNumber, entry.ReasonCode)
+	}
+}
+
+func TestCaService_CrlIsCurrent(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	revoke := func() models.Certificate {
+		cert, err := caService.SignCSR(ca, caKey.PrivateKey, models.CsrSigningRequest{CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:  models.CertificateSubject{CommonName: "svc.test.internal"},
+			DNSNames: []string{"svc.test.internal"},
+		})})
+		if err != nil {
+			t.Fatalf("Setup failed: SignCSR failed: %v", err)
+		}
+		revokedAt := time.Now().UTC()
+		cert.RevokedAt = &revokedAt
+		return cert
+	}
+	first, second := revoke(), revoke()
+
+	der, err := caService.CreateCRL(ca, caKey.PrivateKey, []models.Certificate{first}, 1)
+	if err != nil {
+		t.Fatalf("CreateCRL failed: %v", err)
+	}
+
+	now := time.Now()
+	if !caService.CrlIsCurrent(der, []models.Certificate{first}, now) {
+		t.Error("A fresh CRL listing every revocation must be current")
+	}
+	if caService.CrlIsCurrent(nil, nil, now) {
+		t.Error("A missing CRL must not be current")
+	}
+	if caService.CrlIsCurrent(der, []models.Certificate{second, first}, now) {
+		t.Error("A CRL missing a revocation must not be current")
+	}
+	if caService.CrlIsCurrent(der, []models.Certificate{first}, now.Add(processors.CrlValidity-time.Hour)) {
+		t.Error("A CRL close to its next update must not be current")
+	}
+}
+
+func TestCaService_CheckCaCertificate(t *testing.T) {
+	pair, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	leaf, err := certificateService.SelfSign(pair.PrivateKey, models.CertificateTemplate{
+		Subject: models.CertificateSubject{CommonName: "leaf"},
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: SelfSign failed: %v", err)
+	}
+	if err := caService.CheckCaCertificate(leaf.CertificatePEM, pair.PublicKey); !errors.Is(err, processors.ErrInvalidCertificateRequest) {
+		t.Errorf("Expected a non-CA certificate to be rejected, got: %v", err)
+	}
+
+	ca, _ := newTestCA(t)
+	if err := caService.CheckCaCertificate(ca.CertificatePEM, pair.PublicKey); !errors.Is(err, processors.ErrInvalidCertificateRequest) {
+		t.Errorf("Expected a CA certificate of another key to be rejected, got: %v", err)
+	}
+}
+
+func TestCaService_SubordinateNameConstraints(t *testing.T) {
+	root, rootKey := newTestCA(t)
+	root.Policy.AllowSubordinateCAs = true
+
+	subKey, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate sub CA keys: %v", err)
+	}
+	subCSR, err := certificateService.CreateCSR(subKey.PrivateKey, models.CertificateTemplate{
+		Subject: models.CertificateSubject{CommonName: "Test Issuing CA"},
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: Could not create sub CA CSR: %v", err)
+	}
+	subCert, err := caService.SignCSR(root, rootKey.PrivateKey, models.CsrSigningRequest{CSR: subCSR, IsCA: true})
+	if err != nil {
+		t.Fatalf("SignCSR for a sub CA failed: %v", err)
+	}
+	block, _ := pem.Decode([]byte(subCert.CertificatePEM))
+	parsed, _ := x509.ParseCertificate(block.Bytes)
+	if !parsed.PermittedDNSDomainsCritical || len(parsed.PermittedDNSDomains) != 1 || parsed.PermittedDNSDomains[0] != "test.internal" {
+		t.Errorf("Expected critical name constraints for test.internal, got %v (critical %v)", parsed.PermittedDNSDomains, parsed.PermittedDNSDomainsCritical)
+	}
+
+	// Even if the sub CA itself had a broader policy, certificates it issues for
+	// foreign names must not chain to the root.
+	sub := models.CertificateAuthority{
+		ID:             2,
+		CertificatePEM: subCert.CertificatePEM,
+		Policy:         models.CaPolicy{AllowedDomains: []string{"evil.com", "test.internal"}, MaxValidityDays: 30},
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(root.CertificatePEM))
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(parsed)
+	for name, valid := range map[string]bool{"svc.test.internal": true, "www.evil.com": false} {
+		leaf, err := caService.SignCSR(sub, subKey.PrivateKey, models.CsrSigningRequest{CSR: newTestCSR(t, models.CertificateTemplate{
+			Subject:  models.CertificateSubject{CommonName: name},
+			DNSNames: []string{name},
+		})})
+		if err != nil {
+			t.Fatalf("SignCSR by the sub CA failed: %v", err)
+		}
+		block, _ := pem.Decode([]byte(leaf.CertificatePEM))
+		leafCert, _ := x509.ParseCertificate(block.Bytes)
+		_, err = leafCert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, DNSName: name})
+		if valid && err != nil {
+			t.Errorf("Expected %s to chain to the root: %v", name, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Expected %s to violate the sub CA name constraints", name)
+		}
+	}
+}
+
+func TestCaService_CheckSubordinatePolicy(t *testing.T) {
+	parent := models.CaPolicy{AllowedDomains: []string{"test.internal"}, MaxValidityDays: 90}
+
+	if err := caService.CheckSubordinatePolicy(parent, models.CaPolicy{AllowedDomains: []string{"dev.test.internal"}, MaxValidityDays: 30}); err != nil {
+		t.Errorf("Expected a narrower policy to be accepted, got: %v", err)
+	}
+
+	invalid := map[string]models.CaPolicy{
+		"foreign domain":  {AllowedDomains: []string{"evil.com"}, MaxValidityDays: 30},
+		"wider domain":    {AllowedDomains: []string{"internal"}, MaxValidityDays: 30},
+		"IP addresses":    {AllowedDomains: []string{"test.internal"}, AllowIPAddresses: true, MaxValidityDays: 30},
+		"subordinate CAs": {AllowedDomains: []string{"test.internal"}, AllowSubordinateCAs: true, MaxValidityDays: 30},
+		"validity":        {AllowedDomains: []string{"test.internal"}, MaxValidityDays: 365},
+	}
+	for name, child := range invalid {
+		if err := caService.CheckSubordinatePolicy(parent, child); !errors.Is(err, processors.ErrCaPolicyViolation) {
+			t.Errorf("%s: expected ErrCaPolicyViolation, got: %v", name, err)
+		}
+	}
+}
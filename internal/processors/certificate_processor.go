@@ -0,0 +1,318 @@
+package processors
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"math/big"
+	"net"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultCertificateValidityDays is used when a template does not set a validity.
+	DefaultCertificateValidityDays = 365
+	// MaxCertificateValidityDays bounds the validity of any issued certificate.
+	MaxCertificateValidityDays = 3650
+)
+
+// ErrInvalidCertificateRequest is returned for a certificate template that cannot be honoured.
+var ErrInvalidCertificateRequest = errors.New("invalid certificate request")
+
+var keyUsageNames = map[string]x509.KeyUsage{
+	"digitalSignature":  x509.KeyUsageDigitalSignature,
+	"contentCommitment": x509.KeyUsageContentCommitment,
+	"keyEncipherment":   x509.KeyUsageKeyEncipherment,
+	"dataEncipherment":  x509.KeyUsageDataEncipherment,
+	"keyAgreement":      x509.KeyUsageKeyAgreement,
+	"keyCertSign":       x509.KeyUsageCertSign,
+	"cRLSign":           x509.KeyUsageCRLSign,
+}
+
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"serverAuth":      x509.ExtKeyUsageServerAuth,
+	"clientAuth":      x509.ExtKeyUsageClientAuth,
+	"codeSigning":     x509.ExtKeyUsageCodeSigning,
+	"emailProtection": x509.ExtKeyUsageEmailProtection,
+	"timeStamping":    x509.ExtKeyUsageTimeStamping,
+	"ocspSigning":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// extKeyUsageOIDs are the RFC 5280 object identifiers of the supported extended key usages.
+var extKeyUsageOIDs = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+	x509.ExtKeyUsageServerAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	x509.ExtKeyUsageClientAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	x509.ExtKeyUsageCodeSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	x509.ExtKeyUsageEmailProtection: {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	x509.ExtKeyUsageTimeStamping:    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	x509.ExtKeyUsageOCSPSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+var (
+	oidExtensionKeyUsage    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// ICertificateService builds PKCS#10 CSRs and X.509 certificates for stored keys.
+type ICertificateService interface {
+	CreateCSR(privateKeyPEM string, template models.CertificateTemplate) (string, error)
+	SelfSign(privateKeyPEM string, template models.CertificateTemplate) (models.Certificate, error)
+}
+
+type CertificateService struct{}
+
+func NewCertificateService() *CertificateService {
+	return &CertificateService{}
+}
+
+// CreateCSR builds a PEM encoded PKCS#10 certificate signing request. Explicit key
+// usages and extended key usages are included as requested extensions; the issuing
+// CA decides whether to grant them.
+func (s *CertificateService) CreateCSR(privateKeyPEM string, template models.CertificateTemplate) (string, error) {
+	signer, err := parseSigner(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	cert, err := buildCertificateTemplate(template, signer.Public())
+	if err != nil {
+		return "", err
+	}
+
+	extensions, err := requestedUsageExtensions(template, cert)
+	if err != nil {
+		return "", err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:         cert.Subject,
+		DNSNames:        cert.DNSNames,
+		IPAddresses:     cert.IPAddresses,
+		EmailAddresses:  cert.EmailAddresses,
+		URIs:            cert.URIs,
+		ExtraExtensions: extensions,
+	}, signer)
+	if err != nil {
+		return "", fmt.Errorf("failed to create CSR: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})), nil
+}
+
+// SelfSign issues a certificate for the key signed by the key itself.
+func (s *CertificateService) SelfSign(privateKeyPEM string, template models.CertificateTemplate) (models.Certificate, error) {
+	signer, err := parseSigner(privateKeyPEM)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+	cert, err := buildCertificateTemplate(template, signer.Public())
+	if err != nil {
+		return models.Certificate{}, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, cert, cert, signer.Public(), signer)
+	if err != nil {
+		return models.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return certificateFromDER(der)
+}
+
+// buildCertificateTemplate validates a template and converts it to an x509.Certificate
+// with a random serial number and a validity window starting now.
+func buildCertificateTemplate(template models.CertificateTemplate, publicKey crypto.PublicKey) (*x509.Certificate, error) {
+	if template.Subject.CommonName == "" {
+		return nil, fmt.Errorf("%w: subject.commonName is required", ErrInvalidCertificateRequest)
+	}
+
+	days := template.ValidityDays
+	if days == 0 {
+		days = DefaultCertificateValidityDays
+	}
+	if days < 1 || days > MaxCertificateValidityDays {
+		return nil, fmt.Errorf("%w: validityDays must be between 1 and %d", ErrInvalidCertificateRequest, MaxCertificateValidityDays)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cert := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               certificateName(template.Subject),
+		NotBefore:             now,
+		NotAfter:              now.AddDate(0, 0, days),
+		DNSNames:              template.DNSNames,
+		EmailAddresses:        template.EmailAddresses,
+		BasicConstraintsValid: true,
+		IsCA:                  template.IsCA,
+	}
+
+	for _, value := range template.IPAddresses {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("%w: invalid IP address %q", ErrInvalidCertificateRequest, value)
+		}
+		cert.IPAddresses = append(cert.IPAddresses, ip)
+	}
+	for _, value := range template.URIs {
+		uri, err := url.Parse(value)
+		if err != nil || uri.Scheme == "" {
+			return nil, fmt.Errorf("%w: invalid URI %q", ErrInvalidCertificateRequest, value)
+		}
+		cert.URIs = append(cert.URIs, uri)
+	}
+
+	keyUsage, extKeyUsage := template.KeyUsage, template.ExtKeyUsage
+	if len(keyUsage) == 0 {
+		keyUsage = defaultKeyUsage(publicKey, template.IsCA)
+	}
+	if len(extKeyUsage) == 0 && !template.IsCA {
+		extKeyUsage = []string{"serverAuth"}
+	}
+	for _, name := range keyUsage {
+		usage, ok := keyUsageNames[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown key usage %q", ErrInvalidCertificateRequest, name)
+		}
+		cert.KeyUsage |= usage
+	}
+	for _, name := range extKeyUsage {
+		usage, ok := extKeyUsageNames[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown extended key usage %q", ErrInvalidCertificateRequest, name)
+		}
+		cert.ExtKeyUsage = append(cert.ExtKeyUsage, usage)
+	}
+	if template.IsCA && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("%w: CA certificates need the keyCertSign key usage", ErrInvalidCertificateRequest)
+	}
+
+	return cert, nil
+}
+
+// requestedUsageExtensions encodes explicitly requested key usages and extended key
+// usages as CSR extension requests. Defaults are left to the issuing CA.
+func requestedUsageExtensions(template models.CertificateTemplate, cert *x509.Certificate) ([]pkix.Extension, error) {
+	var extensions []pkix.Extension
+
+	if len(template.KeyUsage) > 0 {
+		var bits asn1.BitString
+		for i := 0; i < 9; i++ {
+			if cert.KeyUsage&(1<<i) != 0 {
+				bits.BitLength = i + 1
+			}
+		}
+		bits.Bytes = make([]byte, (bits.BitLength+7)/8)
+		for i := 0; i < bits.BitLength; i++ {
+			if cert.KeyUsage&(1<<i) != 0 {
+				bits.Bytes[i/8] |= 0x80 >> (i % 8)
+			}
+		}
+		value, err := asn1.Marshal(bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode key usage: %w", err)
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value})
+	}
+
+	if len(template.ExtKeyUsage) > 0 {
+		oids := make([]asn1.ObjectIdentifier, len(cert.ExtKeyUsage))
+		for i, usage := range cert.ExtKeyUsage {
+			oids[i] = extKeyUsageOIDs[usage]
+		}
+		value, err := asn1.Marshal(oids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode extended key usage: %w", err)
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionExtKeyUsage, Value: value})
+	}
+
+	return extensions, nil
+}
+
+// defaultKeyUsage follows common TLS profiles: RSA keys also encipher session keys,
+// CA keys sign certificates and CRLs.
+func defaultKeyUsage(publicKey crypto.PublicKey, isCA bool) []string {
+	if isCA {
+		return []string{"digitalSignature", "keyCertSign", "cRLSign"}
+	}
+	if _, ok := publicKey.(*rsa.PublicKey); ok {
+		return []string{"digitalSignature", "keyEncipherment"}
+	}
+	return []string{"digitalSignature"}
+}
+
+func certificateName(subject models.CertificateSubject) pkix.Name {
+	name := pkix.Name{CommonName: subject.CommonName}
+	if subject.Organization != "" {
+		name.Organization = []string{subject.Organization}
+	}
+	if subject.OrganizationalUnit != "" {
+		name.OrganizationalUnit = []string{subject.OrganizationalUnit}
+	}
+	if subject.Country != "" {
+		name.Country = []string{subject.Country}
+	}
+	if subject.Province != "" {
+		name.Province = []string{subject.Province}
+	}
+	if subject.Locality != "" {
+		name.Locality = []string{subject.Locality}
+	}
+	return name
+}
+
+// certificateFromDER describes an issued certificate for storage.
+func certificateFromDER(der []byte) (models.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return models.Certificate{}, fmt.Errorf("failed to parse issued certificate: %w", err)
+	}
+
+	return models.Certificate{
+		SerialNumber:   cert.SerialNumber.Text(16),
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+		IsCA:           cert.IsCA,
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}, nil
+}
+
+// parseSigner parses a stored PKCS#1 or PKCS#8 private key that can sign certificates.
+func parseSigner(privateKeyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM block")
+	}
+
+	var key any
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return key.(crypto.Signer), nil
+	default:
+		return nil, fmt.Errorf("%w: %T cannot sign certificates", ErrUnsupportedKeyAlgorithm, key)
+	}
+}
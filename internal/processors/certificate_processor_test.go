@@ -0,0 +1,157 @@
+package processors_test
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var certificateService = processors.NewCertificateService()
+
+func TestCertificateService_SelfSign(t *testing.T) {
+	pair, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	cert, err := certificateService.SelfSign(pair.PrivateKey, models.CertificateTemplate{
+		Subject:      models.CertificateSubject{CommonName: "api.internal", Organization: "Laba6"},
+		DNSNames:     []string{"api.internal", "localhost"},
+		IPAddresses:  []string{"127.0.0.1"},
+		ExtKeyUsage:  []string{"serverAuth", "clientAuth"},
+		ValidityDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("SelfSign failed: %v", err)
+	}
+
+	block, _ := pem.Decode([]byte(cert.CertificatePEM))
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Issued certificate does not parse: %v", err)
+	}
+	if err := parsed.CheckSignature(parsed.SignatureAlgorithm, parsed.RawTBSCertificate, parsed.Signature); err != nil {
+		t.Errorf("Certificate is not self-signed: %v", err)
+	}
+	if err := parsed.VerifyHostname("api.internal"); err != nil {
+		t.Errorf("Certificate does not cover its DNS name: %v", err)
+	}
+	if cert.Subject != cert.Issuer || cert.IsCA {
+		t.Errorf("Unexpected subject/issuer/CA: %s / %s / %v", cert.Subject, cert.Issuer, cert.IsCA)
+	}
+	if days := cert.NotAfter.Sub(cert.NotBefore).Hours() / 24; days != 30 {
+		t.Errorf("Expected 30 days of validity, got %v", days)
+	}
+}
+
+func TestCertificateService_CreateCSR(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	csrPEM, err := certificateService.CreateCSR(keys.PrivateKey, models.CertificateTemplate{
+		Subject:  models.CertificateSubject{CommonName: "worker.internal"},
+		DNSNames: []string{"worker.internal"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCSR failed: %v", err)
+	}
+
+	block, _ := pem.Decode([]byte(csrPEM))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("CSR does not parse: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("CSR signature is invalid: %v", err)
+	}
+	if csr.Subject.CommonName != "worker.internal" || len(csr.DNSNames) != 1 {
+		t.Errorf("Unexpected CSR contents: %+v", csr.Subject)
+	}
+	if len(csr.Extensions) != 1 {
+		t.Errorf("Expected only the SAN extension without explicit usages, got %d extensions", len(csr.Extensions))
+	}
+}
+
+func TestCertificateService_CreateCSRRequestsUsages(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	csrPEM, err := certificateService.CreateCSR(keys.PrivateKey, models.CertificateTemplate{
+		Subject:     models.CertificateSubject{CommonName: "worker.internal"},
+		KeyUsage:    []string{"digitalSignature", "keyEncipherment"},
+		ExtKeyUsage: []string{"clientAuth"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCSR failed: %v", err)
+	}
+	block, _ := pem.Decode([]byte(csrPEM))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("CSR does not parse: %v", err)
+	}
+
+	var keyUsage asn1.BitString
+	var extKeyUsage []asn1.ObjectIdentifier
+	for _, extension := range csr.Extensions {
+		switch {
+		case extension.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 15}):
+			if _, err := asn1.Unmarshal(extension.Value, &keyUsage); err != nil || !extension.Critical {
+				t.Errorf("Invalid key usage extension: critical=%v err=%v", extension.Critical, err)
+			}
+		case extension.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 37}):
+			if _, err := asn1.Unmarshal(extension.Value, &extKeyUsage); err != nil {
+				t.Errorf("Invalid extended key usage extension: %v", err)
+			}
+		}
+	}
+	if keyUsage.BitLength != 3 || keyUsage.At(0) != 1 || keyUsage.At(1) != 0 || keyUsage.At(2) != 1 {
+		t.Errorf("Expected digitalSignature and keyEncipherment, got bits %x/%d", keyUsage.Bytes, keyUsage.BitLength)
+	}
+	if len(extKeyUsage) != 1 || !extKeyUsage[0].Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}) {
+		t.Errorf("Expected clientAuth, got %v", extKeyUsage)
+	}
+
+	_, err = certificateService.CreateCSR(keys.PrivateKey, models.CertificateTemplate{
+		Subject:     models.CertificateSubject{CommonName: "worker.internal"},
+		ExtKeyUsage: []string{"everything"},
+	})
+	if !errors.Is(err, processors.ErrInvalidCertificateRequest) {
+		t.Errorf("Expected ErrInvalidCertificateRequest for an unknown usage, got: %v", err)
+	}
+}
+
+func TestCertificateService_RejectsInvalidTemplates(t *testing.T) {
+	pair, err := ecService.GenerateKeyPair(models.KeyAlgorithmEd25519)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	templates := []models.CertificateTemplate{
+		{},
+		{Subject: models.CertificateSubject{CommonName: "x"}, IPAddresses: []string{"not-an-ip"}},
+		{Subject: models.CertificateSubject{CommonName: "x"}, KeyUsage: []string{"everything"}},
+		{Subject: models.CertificateSubject{CommonName: "x"}, ValidityDays: processors.MaxCertificateValidityDays + 1},
+		{Subject: models.CertificateSubject{CommonName: "x"}, IsCA: true, KeyUsage: []string{"digitalSignature"}},
+	}
+	for i, template := range templates {
+		if _, err := certificateService.SelfSign(pair.PrivateKey, template); !errors.Is(err, processors.ErrInvalidCertificateRequest) {
+			t.Errorf("Template %d: expected ErrInvalidCertificateRequest, got: %v", i, err)
+		}
+	}
+
+	x, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate X25519 keys: %v", err)
+	}
+	if _, err := certificateService.CreateCSR(x.PrivateKey, templates[1]); !errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedKeyAlgorithm for an X25519 key, got: %v", err)
+	}
+}
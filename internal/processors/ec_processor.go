@@ -0,0 +1,178 @@
+package processors
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+)
+
+// ErrUnsupportedKeyAlgorithm is returned for an unknown or mismatching key algorithm.
+var ErrUnsupportedKeyAlgorithm = errors.New("unsupported key algorithm")
+
+// IEcService defines elliptic-curve key generation and signature operations.
+type IEcService interface {
+	GenerateKeyPair(algorithm models.KeyAlgorithm) (models.KeyPair, error)
+	Sign(privateKey string, message []byte, hash models.HashAlgorithm) (string, error)
+	Verify(publicKey string, message []byte, signatureBase64 string, hash models.HashAlgorithm) (bool, error)
+}
+
+// EcService implements ECDSA (P-256, P-384) and Ed25519 signatures.
+// ECDSA signatures are ASN.1 DER encoded; Ed25519 signs the message directly.
+type EcService struct{}
+
+func NewEcService() *EcService {
+	return &EcService{}
+}
+
+// GenerateKeyPair generates a key pair and encodes it as PKCS#8 / PKIX PEM.
+func (s *EcService) GenerateKeyPair(algorithm models.KeyAlgorithm) (models.KeyPair, error) {
+	var privateKey crypto.PrivateKey
+	var publicKey crypto.PublicKey
+
+	switch algorithm {
+	case models.KeyAlgorithmECDSAP256, models.KeyAlgorithmECDSAP384:
+		key, err := ecdsa.GenerateKey(ecdsaCurve(algorithm), rand.Reader)
+		if err != nil {
+			return models.KeyPair{}, fmt.Errorf("failed to generate private key: %w", err)
+		}
+		privateKey, publicKey = key, &key.PublicKey
+	case models.KeyAlgorithmEd25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return models.KeyPair{}, fmt.Errorf("failed to generate private key: %w", err)
+		}
+		privateKey, publicKey = priv, pub
+	default:
+		return models.KeyPair{}, fmt.Errorf("%w: %q", ErrUnsupportedKeyAlgorithm, algorithm)
+	}
+
+	privatePEM, publicPEM, err := encodeKeyPairPEM(privateKey, publicKey)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+
+	return models.KeyPair{
+		Algorithm:  algorithm,
+		KeySize:    ecKeySize(algorithm),
+		PrivateKey: privatePEM,
+		PublicKey:  publicPEM,
+	}, nil
+}
+
+// Sign signs the message with an ECDSA or Ed25519 private key.
+// An empty hash selects the curve's natural digest (SHA-256 for P-256,
+// SHA-384 for P-384); Ed25519 does not accept a hash.
+func (s *EcService) Sign(privateKeyPEM string, message []byte, hash models.HashAlgorithm) (string, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return "", fmt.Errorf("failed to decode private key PEM block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	var signature []byte
+	switch priv := key.(type) {
+	case *ecdsa.PrivateKey:
+		_, digest, err := digestMessage(ecdsaHash(priv.Curve, hash), message)
+		if err != nil {
+			return "", err
+		}
+		signature, err = ecdsa.SignASN1(rand.Reader, priv, digest)
+		if err != nil {
+			return "", fmt.Errorf("signing failed: %w", err)
+		}
+	case ed25519.PrivateKey:
+		if hash != "" {
+			return "", fmt.Errorf("%w: Ed25519 does not use a separate hash", ErrUnsupportedHash)
+		}
+		signature = ed25519.Sign(priv, message)
+	default:
+		return "", fmt.Errorf("%w: key is not an ECDSA or Ed25519 private key", ErrUnsupportedKeyAlgorithm)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a base64 encoded ECDSA or Ed25519 signature over the message.
+func (s *EcService) Verify(publicKeyPEM string, message []byte, signatureBase64 string, hash models.HashAlgorithm) (bool, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return false, fmt.Errorf("failed to decode public key PEM block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode base64 signature: %w", err)
+	}
+
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		_, digest, err := digestMessage(ecdsaHash(pub.Curve, hash), message)
+		if err != nil {
+			return false, err
+		}
+		return ecdsa.VerifyASN1(pub, digest, signature), nil
+	case ed25519.PublicKey:
+		if hash != "" {
+			return false, fmt.Errorf("%w: Ed25519 does not use a separate hash", ErrUnsupportedHash)
+		}
+		return ed25519.Verify(pub, message, signature), nil
+	default:
+		return false, fmt.Errorf("%w: key is not an ECDSA or Ed25519 public key", ErrUnsupportedKeyAlgorithm)
+	}
+}
+
+// ecKeySize returns the key size in bits recorded for an elliptic-curve algorithm.
+func ecKeySize(algorithm models.KeyAlgorithm) int {
+	if algorithm == models.KeyAlgorithmECDSAP384 {
+		return 384
+	}
+	return 256
+}
+
+func ecdsaCurve(algorithm models.KeyAlgorithm) elliptic.Curve {
+	if algorithm == models.KeyAlgorithmECDSAP384 {
+		return elliptic.P384()
+	}
+	return elliptic.P256()
+}
+
+func ecdsaHash(curve elliptic.Curve, hash models.HashAlgorithm) models.HashAlgorithm {
+	if hash != "" {
+		return hash
+	}
+	if curve == elliptic.P384() {
+		return models.HashSHA384
+	}
+	return models.HashSHA256
+}
+
+// encodeKeyPairPEM encodes a private key as PKCS#8 and a public key as PKIX PEM.
+func encodeKeyPairPEM(privateKey crypto.PrivateKey, publicKey crypto.PublicKey) (string, string, error) {
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	publicBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+	return string(privatePEM), string(publicPEM), nil
+}
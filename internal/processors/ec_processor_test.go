@@ -0,0 +1,73 @@
+package processors_test
+
+import (
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var ecService = processors.NewEcService()
+
+func TestEcService_GenerateKeyPair(t *testing.T) {
+	for _, algorithm := range []models.KeyAlgorithm{models.KeyAlgorithmECDSAP256, models.KeyAlgorithmECDSAP384, models.KeyAlgorithmEd25519} {
+		pair, err := ecService.GenerateKeyPair(algorithm)
+		if err != nil {
+			t.Fatalf("GenerateKeyPair(%s) failed unexpectedly: %v", algorithm, err)
+		}
+
+		if pair.Algorithm != algorithm {
+			t.Errorf("Expected algorithm %s, got %s", algorithm, pair.Algorithm)
+		}
+		if pair.PublicKey == "" || pair.PrivateKey == "" {
+			t.Errorf("Keys for %s should not be empty", algorithm)
+		}
+	}
+
+	if _, err := ecService.GenerateKeyPair("ECDSA-P521"); !errors.Is(err, processors.ErrUnsupportedKeyAlgorithm) {
+		t.Errorf("Expected unsupported algorithm error, got: %v", err)
+	}
+}
+
+func TestEcService_SignVerify(t *testing.T) {
+	const message = "Small and fast signatures"
+
+	for _, algorithm := range []models.KeyAlgorithm{models.KeyAlgorithmECDSAP256, models.KeyAlgorithmECDSAP384, models.KeyAlgorithmEd25519} {
+		pair, err := ecService.GenerateKeyPair(algorithm)
+		if err != nil {
+			t.Fatalf("Setup failed: Could not generate %s keys: %v", algorithm, err)
+		}
+
+		signature, err := ecService.Sign(pair.PrivateKey, []byte(message), "")
+		if err != nil {
+			t.Fatalf("Sign with %s failed: %v", algorithm, err)
+		}
+
+		valid, err := ecService.Verify(pair.PublicKey, []byte(message), signature, "")
+		if err != nil {
+			t.Fatalf("Verify with %s failed: %v", algorithm, err)
+		}
+		if !valid {
+			t.Errorf("%s signature should be valid", algorithm)
+		}
+
+		valid, err = ecService.Verify(pair.PublicKey, []byte(message+"."), signature, "")
+		if err != nil {
+			t.Fatalf("Verify with %s of modified message failed: %v", algorithm, err)
+		}
+		if valid {
+			t.Errorf("%s signature should not be valid for a modified message", algorithm)
+		}
+	}
+}
+
+func TestEcService_Sign_Ed25519RejectsHash(t *testing.T) {
+	pair, err := ecService.GenerateKeyPair(models.KeyAlgorithmEd25519)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	if _, err := ecService.Sign(pair.PrivateKey, []byte("msg"), models.HashSHA512); !errors.Is(err, processors.ErrUnsupportedHash) {
+		t.Errorf("Expected unsupported hash error for Ed25519, got: %v", err)
+	}
+}
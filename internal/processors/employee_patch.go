@@ -0,0 +1,66 @@
+package processors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"laba6/internal/models"
+	"reflect"
+)
+
+// employeeReadOnlyFields cannot be changed by a patch. A patch may still carry
+// them with their current values, e.g. a merge patch built from a fetched employee.
+var employeeReadOnlyFields = []string{"id", "created_at", "updated_at", "version"}
+
+// employeeEditableFields must be present after a patch; removing one is the same
+// as leaving it empty on create.
+var employeeEditableFields = []string{"name", "position", "department", "salary"}
+
+// ApplyEmployeePatch applies a JSON Merge Patch or JSON Patch to the JSON form of
+// employee and returns the patched employee. Validation of the patched values is
+// left to the repository, which shares it with create.
+func ApplyEmployeePatch(employee models.Employee, patch []byte, patchType models.PatchType) (models.Employee, error) {
+	doc, err := json.Marshal(employee)
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	var patched []byte
+	switch patchType {
+	case models.PatchTypeMerge:
+		patched, err = ApplyMergePatch(doc, patch)
+	case models.PatchTypeJSON:
+		patched, err = ApplyJSONPatch(doc, patch)
+	default:
+		return models.Employee{}, fmt.Errorf("%w: unsupported patch type %q", ErrInvalidPatch, patchType)
+	}
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	var before, after map[string]any
+	if err := json.Unmarshal(doc, &before); err != nil {
+		return models.Employee{}, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return models.Employee{}, fmt.Errorf("%w: patched employee must be an object", ErrInvalidPatch)
+	}
+	for _, field := range employeeReadOnlyFields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			return models.Employee{}, fmt.Errorf("%w: %s is read-only", ErrInvalidPatch, field)
+		}
+	}
+	for _, field := range employeeEditableFields {
+		if after[field] == nil {
+			return models.Employee{}, fmt.Errorf("%s is required", field)
+		}
+	}
+
+	var result models.Employee
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return models.Employee{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package processors_test
+
+import (
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEmployee() models.Employee {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return models.Employee{ID: 7, Name: "Ann", Position: "Engineer", Department: "R&D", Salary: 5000, CreatedAt: created, UpdatedAt: created}
+}
+
+func TestApplyEmployeePatch(t *testing.T) {
+	employee := testEmployee()
+
+	merged, err := processors.ApplyEmployeePatch(employee, []byte(`{"salary":6000.5,"id":7}`), models.PatchTypeMerge)
+	if err != nil {
+		t.Fatalf("Merge patch failed: %v", err)
+	}
+	if merged.Salary != 6000.5 || merged.Name != "Ann" || merged.Position != "Engineer" {
+		t.Errorf("Merge patch changed the wrong fields: %+v", merged)
+	}
+
+	patch := `[{"op":"test","path":"/position","value":"Engineer"},{"op":"replace","path":"/position","value":"Lead"},{"op":"move","from":"/name","path":"/department"},{"op":"add","path":"/name","value":"Bob"}]`
+	patched, err := processors.ApplyEmployeePatch(employee, []byte(patch), models.PatchTypeJSON)
+	if err != nil {
+		t.Fatalf("JSON Patch failed: %v", err)
+	}
+	if patched.Position != "Lead" || patched.Department != "Ann" || patched.Name != "Bob" || patched.Salary != 5000 {
+		t.Errorf("JSON Patch produced %+v", patched)
+	}
+}
+
+func TestApplyEmployeePatch_Invalid(t *testing.T) {
+	employee := testEmployee()
+
+	invalid := map[string]models.PatchType{
+		`{"id":8}`:                             models.PatchTypeMerge,
+		`{"created_at":null}`:                  models.PatchTypeMerge,
+		`{"salary":"a lot"}`:                   models.PatchTypeMerge,
+		`{"manager":"Bob"}`:                    models.PatchTypeMerge,
+		`[{"op":"remove","path":"/id"}]`:       models.PatchTypeJSON,
+		`{"name":"Bob"}`:                       models.PatchTypeJSON,
+		`[{"op":"add","path":"/x","value":1}]`: models.PatchTypeJSON,
+	}
+	for patch, patchType := range invalid {
+		if _, err := processors.ApplyEmployeePatch(employee, []byte(patch), patchType); !errors.Is(err, processors.ErrInvalidPatch) {
+			t.Errorf("Expected ErrInvalidPatch for %s, got: %v", patch, err)
+		}
+	}
+
+	if _, err := processors.ApplyEmployeePatch(employee, []byte(`{"name":null}`), models.PatchTypeMerge); err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("Expected removing name to fail as required, got: %v", err)
+	}
+}
@@ -1,34 +1,117 @@
 package processors
 
 import (
+	"errors"
 	"laba6/internal/models"
 	"laba6/internal/repositories"
 )
 
+// ErrEmployeeVersionRequired is returned in strict mode for a write without an If-Match precondition.
+var ErrEmployeeVersionRequired = errors.New("employee writes require an If-Match precondition")
+
 type EmployeeProcessor struct {
 	repo *repositories.EmployeeRepository
+	// requireVersion rejects unconditional updates and deletes.
+	requireVersion bool
 }
 
-func NewEmployeeProcessor(repo *repositories.EmployeeRepository) *EmployeeProcessor {
-	return &EmployeeProcessor{repo: repo}
+func NewEmployeeProcessor(repo *repositories.EmployeeRepository, requireVersion bool) *EmployeeProcessor {
+	return &EmployeeProcessor{repo: repo, requireVersion: requireVersion}
 }
 
-func (p *EmployeeProcessor) GetAllEmployees() ([]models.Employee, error) {
-	return p.repo.GetAll()
+// ListEmployees returns one page of employees matching the query. One row more than
+// the limit is fetched to learn whether another page follows.
+func (p *EmployeeProcessor) ListEmployees(query models.EmployeeListQuery) (models.EmployeePage, error) {
+	if err := normalizeEmployeeQuery(&query); err != nil {
+		return models.EmployeePage{}, err
+	}
+	sort, err := ParseEmployeeSort(query.Sort)
+	if err != nil {
+		return models.EmployeePage{}, err
+	}
+	var after []string
+	if query.Cursor != "" {
+		if after, err = DecodeEmployeeCursor(query.Cursor, sort); err != nil {
+			return models.EmployeePage{}, err
+		}
+	}
+
+	employees, total, err := p.repo.List(query, sort, after)
+	if err != nil {
+		return models.EmployeePage{}, err
+	}
+
+	page := models.EmployeePage{Data: employees, Pagination: models.PaginationInfo{Limit: query.Limit, Total: total}}
+	if query.Cursor == "" {
+		page.Pagination.Offset = &query.Offset
+	}
+	if len(employees) > query.Limit {
+		page.Data = employees[:query.Limit]
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = EncodeEmployeeCursor(page.Data[query.Limit-1], sort)
+	}
+	return page, nil
+}
+
+// SearchEmployees runs a full-text search and returns one page of results ordered by rank.
+func (p *EmployeeProcessor) SearchEmployees(query models.EmployeeSearchQuery) (models.EmployeeSearchPage, error) {
+	if err := normalizePage(&query.Limit, query.Offset); err != nil {
+		return models.EmployeeSearchPage{}, err
+	}
+	tsQuery, err := ParseEmployeeSearch(query.Q)
+	if err != nil {
+		return models.EmployeeSearchPage{}, err
+	}
+
+	results, total, err := p.repo.Search(tsQuery, query.Limit, query.Offset)
+	if err != nil {
+		return models.EmployeeSearchPage{}, err
+	}
+
+	page := models.EmployeeSearchPage{Data: results, Pagination: models.PaginationInfo{Limit: query.Limit, Offset: &query.Offset, Total: total}}
+	if len(results) > query.Limit {
+		page.Data = results[:query.Limit]
+		page.Pagination.HasMore = true
+	}
+	return page, nil
 }
 
 func (p *EmployeeProcessor) GetEmployeeByID(id int) (*models.Employee, error) {
 	return p.repo.GetByID(id)
 }
 
-func (p *EmployeeProcessor) CreateEmployee(name, position, department string, salary float64) error {
+func (p *EmployeeProcessor) CreateEmployee(name, position, department string, salary float64) (*models.Employee, error) {
 	return p.repo.Create(name, position, department, salary)
 }
 
-func (p *EmployeeProcessor) DeleteEmployee(id int) error {
-	return p.repo.Delete(id)
+func (p *EmployeeProcessor) DeleteEmployee(id int, ifMatch models.IfMatch) error {
+	if err := p.checkPrecondition(ifMatch); err != nil {
+		return err
+	}
+	return p.repo.Delete(id, ifMatch)
+}
+
+func (p *EmployeeProcessor) UpdateEmployee(id int, ifMatch models.IfMatch, name, position, department string, salary float64) (*models.Employee, error) {
+	if err := p.checkPrecondition(ifMatch); err != nil {
+		return nil, err
+	}
+	return p.repo.Update(id, ifMatch, name, position, department, salary)
+}
+
+// PatchEmployee applies a JSON Merge Patch or JSON Patch to an employee and stores
+// the changed fields. The patch is applied to the row as locked for the update.
+func (p *EmployeeProcessor) PatchEmployee(id int, ifMatch models.IfMatch, patch []byte, patchType models.PatchType) (*models.Employee, error) {
+	if err := p.checkPrecondition(ifMatch); err != nil {
+		return nil, err
+	}
+	return p.repo.Patch(id, ifMatch, func(employee models.Employee) (models.Employee, error) {
+		return ApplyEmployeePatch(employee, patch, patchType)
+	})
 }
 
-func (p *EmployeeProcessor) UpdateEmployee(id int, name, position, department string, salary float64) error {
-	return p.repo.Update(id, name, position, department, salary)
+func (p *EmployeeProcessor) checkPrecondition(ifMatch models.IfMatch) error {
+	if p.requireVersion && !ifMatch.Present {
+		return ErrEmployeeVersionRequired
+	}
+	return nil
 }
@@ -0,0 +1,23 @@
+package processors_test
+
+import (
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+func TestEmployeeProcessor_RequiresIfMatch(t *testing.T) {
+	// The precondition is checked before the repository is used.
+	processor := processors.NewEmployeeProcessor(nil, true)
+
+	if _, err := processor.UpdateEmployee(1, models.IfMatch{}, "Ann", "Engineer", "R&D", 5000); !errors.Is(err, processors.ErrEmployeeVersionRequired) {
+		t.Errorf("Expected ErrEmployeeVersionRequired for update, got: %v", err)
+	}
+	if _, err := processor.PatchEmployee(1, models.IfMatch{}, []byte(`{}`), models.PatchTypeMerge); !errors.Is(err, processors.ErrEmployeeVersionRequired) {
+		t.Errorf("Expected ErrEmployeeVersionRequired for patch, got: %v", err)
+	}
+	if err := processor.DeleteEmployee(1, models.IfMatch{}); !errors.Is(err, processors.ErrEmployeeVersionRequired) {
+		t.Errorf("Expected ErrEmployeeVersionRequired for delete, got: %v", err)
+	}
+}
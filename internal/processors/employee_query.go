@@ -0,0 +1,203 @@
+package processors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultEmployeePageSize is used when a list request has no limit.
+	DefaultEmployeePageSize = 20
+	// MaxEmployeePageSize is the largest page a list request may ask for.
+	MaxEmployeePageSize = 100
+	// MaxEmployeeSearchWords bounds the number of words in a search.
+	MaxEmployeeSearchWords = 16
+)
+
+// ErrInvalidEmployeeQuery is returned for list parameters that cannot be satisfied.
+var ErrInvalidEmployeeQuery = errors.New("invalid employee query")
+
+// EmployeeSortFields are the fields employees can be sorted by.
+var EmployeeSortFields = []string{"id", "name", "position", "department", "salary", "created_at", "updated_at"}
+
+// employeeCursor is the opaque keyset position handed out as next_cursor: the sort
+// it belongs to and the sort key values of the last row of the page.
+type employeeCursor struct {
+	Sort  string   `json:"s"`
+	After []string `json:"a"`
+}
+
+// ParseEmployeeSort parses a sort parameter like "department,-salary". id is always
+// appended as the final key so the order is total and keyset pagination is stable.
+func ParseEmployeeSort(sort string) ([]models.EmployeeSort, error) {
+	var keys []models.EmployeeSort
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := models.EmployeeSort{Field: strings.TrimPrefix(part, "-"), Descending: strings.HasPrefix(part, "-")}
+		if !slices.Contains(EmployeeSortFields, key.Field) {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidEmployeeQuery, key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%w: %q is sorted by twice", ErrInvalidEmployeeQuery, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	if !seen["id"] {
+		keys = append(keys, models.EmployeeSort{Field: "id"})
+	}
+	return keys, nil
+}
+
+// EncodeEmployeeCursor returns the cursor that continues after employee in the given sort.
+func EncodeEmployeeCursor(employee models.Employee, sort []models.EmployeeSort) string {
+	after := make([]string, len(sort))
+	for i, key := range sort {
+		after[i] = employeeSortValue(employee, key.Field)
+	}
+
+	data, _ := json.Marshal(employeeCursor{Sort: sortSignature(sort), After: after})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeEmployeeCursor returns the sort key values stored in a cursor. A cursor is
+// only valid for the sort it was issued for, and each value must parse as the type
+// of its sort field so a forged cursor cannot reach the database as a cast error.
+func DecodeEmployeeCursor(cursor string, sort []models.EmployeeSort) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidEmployeeQuery)
+	}
+	var decoded employeeCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.After) != len(sort) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidEmployeeQuery)
+	}
+	if decoded.Sort != sortSignature(sort) {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidEmployeeQuery, decoded.Sort)
+	}
+	for i, key := range sort {
+		if !validEmployeeSortValue(key.Field, decoded.After[i]) {
+			return nil, fmt.Errorf("%w: malformed cursor value for %q", ErrInvalidEmployeeQuery, key.Field)
+		}
+	}
+	return decoded.After, nil
+}
+
+// ParseEmployeeSearch turns free text into a PostgreSQL tsquery that matches rows
+// containing every word as a prefix, e.g. "senior dev" becomes "senior:* & dev:*".
+// Only letters and digits are kept, so the text cannot inject tsquery operators.
+func ParseEmployeeSearch(q string) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "", fmt.Errorf("%w: q must contain at least one word", ErrInvalidEmployeeQuery)
+	}
+	if len(words) > MaxEmployeeSearchWords {
+		return "", fmt.Errorf("%w: q cannot contain more than %d words", ErrInvalidEmployeeQuery, MaxEmployeeSearchWords)
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+// normalizeEmployeeQuery applies the default page size and rejects contradictory parameters.
+func normalizeEmployeeQuery(query *models.EmployeeListQuery) error {
+	if err := normalizePage(&query.Limit, query.Offset); err != nil {
+		return err
+	}
+	if query.Offset > 0 && query.Cursor != "" {
+		return fmt.Errorf("%w: offset and cursor cannot be combined", ErrInvalidEmployeeQuery)
+	}
+	if query.SalaryMin != nil && query.SalaryMax != nil && *query.SalaryMin > *query.SalaryMax {
+		return fmt.Errorf("%w: salary_min is greater than salary_max", ErrInvalidEmployeeQuery)
+	}
+	if !validRange(query.CreatedAfter, query.CreatedBefore) || !validRange(query.UpdatedAfter, query.UpdatedBefore) {
+		return fmt.Errorf("%w: date range ends before it starts", ErrInvalidEmployeeQuery)
+	}
+	return nil
+}
+
+// normalizePage applies the default page size and checks the limit and offset.
+func normalizePage(limit *int, offset int) error {
+	if *limit == 0 {
+		*limit = DefaultEmployeePageSize
+	}
+	if *limit < 1 || *limit > MaxEmployeePageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidEmployeeQuery, MaxEmployeePageSize)
+	}
+	if offset < 0 {
+		return fmt.Errorf("%w: offset cannot be negative", ErrInvalidEmployeeQuery)
+	}
+	return nil
+}
+
+func validRange(from, to *time.Time) bool {
+	return from == nil || to == nil || from.Before(*to)
+}
+
+func sortSignature(sort []models.EmployeeSort) string {
+	parts := make([]string, len(sort))
+	for i, key := range sort {
+		parts[i] = key.Field
+		if key.Descending {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// validEmployeeSortValue reports whether a cursor value parses as the type of field,
+// mirroring the formats employeeSortValue produces.
+func validEmployeeSortValue(field, value string) bool {
+	switch field {
+	case "id":
+		_, err := strconv.Atoi(value)
+		return err == nil
+	case "salary":
+		salary, err := strconv.ParseFloat(value, 64)
+		return err == nil && !math.IsNaN(salary) && !math.IsInf(salary, 0)
+	case "created_at", "updated_at":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// employeeSortValue renders a sort key in a form PostgreSQL parses back into the column type.
+func employeeSortValue(employee models.Employee, field string) string {
+	switch field {
+	case "name":
+		return employee.Name
+	case "position":
+		return employee.Position
+	case "department":
+		return employee.Department
+	case "salary":
+		return strconv.FormatFloat(employee.Salary, 'f', -1, 64)
+	case "created_at":
+		return employee.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return employee.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return strconv.Itoa(employee.ID)
+	}
+}
@@ -0,0 +1,102 @@
+package processors_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseEmployeeSort(t *testing.T) {
+	sort, err := processors.ParseEmployeeSort("department, -salary")
+	if err != nil {
+		t.Fatalf("ParseEmployeeSort failed: %v", err)
+	}
+	expected := []models.EmployeeSort{{Field: "department"}, {Field: "salary", Descending: true}, {Field: "id"}}
+	if !slices.Equal(sort, expected) {
+		t.Errorf("Expected %v with an id tiebreaker, got %v", expected, sort)
+	}
+
+	if sort, _ := processors.ParseEmployeeSort("-id"); !slices.Equal(sort, []models.EmployeeSort{{Field: "id", Descending: true}}) {
+		t.Errorf("An explicit id key should not be duplicated, got %v", sort)
+	}
+
+	for _, invalid := range []string{"password", "name,-name", "salary;DROP TABLE employees"} {
+		if _, err := processors.ParseEmployeeSort(invalid); !errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+			t.Errorf("Expected ErrInvalidEmployeeQuery for sort %q, got: %v", invalid, err)
+		}
+	}
+}
+
+func TestEmployeeCursor_RoundTrip(t *testing.T) {
+	sort, _ := processors.ParseEmployeeSort("-salary,created_at")
+	employee := models.Employee{
+		ID:        42,
+		Salary:    5250.75,
+		CreatedAt: time.Date(2025, 3, 1, 9, 30, 0, 123456000, time.UTC),
+	}
+
+	cursor := processors.EncodeEmployeeCursor(employee, sort)
+	after, err := processors.DecodeEmployeeCursor(cursor, sort)
+	if err != nil {
+		t.Fatalf("DecodeEmployeeCursor failed: %v", err)
+	}
+	if expected := []string{"5250.75", "2025-03-01T09:30:00.123456Z", "42"}; !slices.Equal(after, expected) {
+		t.Errorf("Expected cursor values %v, got %v", expected, after)
+	}
+
+	other, _ := processors.ParseEmployeeSort("salary,created_at")
+	if _, err := processors.DecodeEmployeeCursor(cursor, other); !errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+		t.Errorf("Expected a cursor to be rejected for another sort, got: %v", err)
+	}
+	if _, err := processors.DecodeEmployeeCursor("not a cursor", sort); !errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+		t.Errorf("Expected ErrInvalidEmployeeQuery for a malformed cursor, got: %v", err)
+	}
+}
+
+func TestEmployeeCursor_RejectsMistypedValues(t *testing.T) {
+	sort, _ := processors.ParseEmployeeSort("-salary,updated_at")
+	forge := func(values string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-salary,updated_at,id","a":` + values + `}`))
+	}
+
+	if _, err := processors.DecodeEmployeeCursor(forge(`["1e3","2025-03-01T09:30:00Z","7"]`), sort); err != nil {
+		t.Fatalf("Expected a well-typed cursor to decode, got: %v", err)
+	}
+
+	for _, values := range []string{
+		`["lots","2025-03-01T09:30:00Z","7"]`,
+		`["NaN","2025-03-01T09:30:00Z","7"]`,
+		`["100","yesterday","7"]`,
+		`["100","2025-03-01","7"]`,
+		`["100","2025-03-01T09:30:00Z","7.5"]`,
+		`["100","2025-03-01T09:30:00Z","1 OR 1=1"]`,
+	} {
+		if _, err := processors.DecodeEmployeeCursor(forge(values), sort); !errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+			t.Errorf("Expected ErrInvalidEmployeeQuery for cursor values %s, got: %v", values, err)
+		}
+	}
+}
+
+func TestParseEmployeeSearch(t *testing.T) {
+	tsQuery, err := processors.ParseEmployeeSearch("  Senior dev-ops Иван ")
+	if err != nil {
+		t.Fatalf("ParseEmployeeSearch failed: %v", err)
+	}
+	if expected := "senior:* & dev:* & ops:* & иван:*"; tsQuery != expected {
+		t.Errorf("Expected %q, got %q", expected, tsQuery)
+	}
+
+	if tsQuery, _ := processors.ParseEmployeeSearch("a' | !b:* & (c"); tsQuery != "a:* & b:* & c:*" {
+		t.Errorf("tsquery operators were not stripped: %q", tsQuery)
+	}
+
+	for _, invalid := range []string{"", "  ", "&|!()"} {
+		if _, err := processors.ParseEmployeeSearch(invalid); !errors.Is(err, processors.ErrInvalidEmployeeQuery) {
+			t.Errorf("Expected ErrInvalidEmployeeQuery for q %q, got: %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package processors
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"laba6/internal/models"
+)
+
+// ErrUnsupportedHmacAlgorithm is returned for an unknown HMAC algorithm.
+var ErrUnsupportedHmacAlgorithm = errors.New("unsupported HMAC algorithm")
+
+// IHmacService generates HMAC keys and computes and checks message authentication codes.
+type IHmacService interface {
+	GenerateKey(algorithm models.HmacAlgorithm) (models.HmacKey, error)
+	Sign(key models.HmacKey, message []byte) (string, error)                   // Returns base64 encoded MAC
+	Verify(key models.HmacKey, message []byte, macBase64 string) (bool, error) // mac is base64 encoded
+}
+
+type HmacService struct{}
+
+func NewHmacService() *HmacService {
+	return &HmacService{}
+}
+
+// GenerateKey generates a random key as long as the hash output, as RFC 2104 recommends.
+// An empty algorithm defaults to HMAC-SHA256.
+func (s *HmacService) GenerateKey(algorithm models.HmacAlgorithm) (models.HmacKey, error) {
+	if algorithm == "" {
+		algorithm = models.HmacAlgorithmSHA256
+	}
+	newHash, err := hmacHash(algorithm)
+	if err != nil {
+		return models.HmacKey{}, err
+	}
+
+	key := make([]byte, newHash().Size())
+	if _, err := rand.Read(key); err != nil {
+		return models.HmacKey{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return models.HmacKey{Key: base64.StdEncoding.EncodeToString(key), Algorithm: algorithm}, nil
+}
+
+// Sign computes the MAC of the message with the key's algorithm.
+func (s *HmacService) Sign(key models.HmacKey, message []byte) (string, error) {
+	mac, err := computeHmac(key, message)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(mac), nil
+}
+
+// Verify recomputes the MAC and compares it in constant time. Truncated MACs are rejected.
+func (s *HmacService) Verify(key models.HmacKey, message []byte, macBase64 string) (bool, error) {
+	mac, err := base64.StdEncoding.DecodeString(macBase64)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode base64 MAC: %w", err)
+	}
+	expected, err := computeHmac(key, message)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(mac, expected), nil
+}
+
+func computeHmac(key models.HmacKey, message []byte) ([]byte, error) {
+	newHash, err := hmacHash(key.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := base64.StdEncoding.DecodeString(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HMAC key: %w", err)
+	}
+
+	mac := hmac.New(newHash, secret)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+func hmacHash(algorithm models.HmacAlgorithm) (func() hash.Hash, error) {
+	switch algorithm {
+	case models.HmacAlgorithmSHA256:
+		return sha256.New, nil
+	case models.HmacAlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedHmacAlgorithm, algorithm)
+	}
+}
@@ -0,0 +1,60 @@
+package processors_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var hmacService = processors.NewHmacService()
+
+func TestHmacService_SignVerify(t *testing.T) {
+	for _, algorithm := range []models.HmacAlgorithm{models.HmacAlgorithmSHA256, models.HmacAlgorithmSHA512} {
+		key, err := hmacService.GenerateKey(algorithm)
+		if err != nil {
+			t.Fatalf("GenerateKey(%s) failed: %v", algorithm, err)
+		}
+
+		message := []byte(`{"event":"employee.created","id":42}`)
+		mac, err := hmacService.Sign(key, message)
+		if err != nil {
+			t.Fatalf("Sign(%s) failed: %v", algorithm, err)
+		}
+		raw, _ := base64.StdEncoding.DecodeString(mac)
+		if want := map[models.HmacAlgorithm]int{models.HmacAlgorithmSHA256: 32, models.HmacAlgorithmSHA512: 64}[algorithm]; len(raw) != want {
+			t.Errorf("%s: expected a %d byte MAC, got %d", algorithm, want, len(raw))
+		}
+
+		if valid, err := hmacService.Verify(key, message, mac); err != nil || !valid {
+			t.Errorf("%s: valid MAC rejected: %v", algorithm, err)
+		}
+		if valid, _ := hmacService.Verify(key, append(message, ' '), mac); valid {
+			t.Errorf("%s: MAC accepted for a modified message", algorithm)
+		}
+		truncated := base64.StdEncoding.EncodeToString(raw[:16])
+		if valid, _ := hmacService.Verify(key, message, truncated); valid {
+			t.Errorf("%s: truncated MAC accepted", algorithm)
+		}
+	}
+}
+
+// RFC 4231 test case 2.
+func TestHmacService_KnownAnswer(t *testing.T) {
+	key := models.HmacKey{Key: base64.StdEncoding.EncodeToString([]byte("Jefe")), Algorithm: models.HmacAlgorithmSHA256}
+
+	mac, err := hmacService.Sign(key, []byte("what do ya want for nothing?"))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if want := "W9zBRr9gdU5qBCQmCJV1x1oAPwidJzmDnexYuWTsOEM="; mac != want {
+		t.Errorf("Unexpected HMAC-SHA256: %s", mac)
+	}
+}
+
+func TestHmacService_UnsupportedAlgorithm(t *testing.T) {
+	if _, err := hmacService.GenerateKey("HMAC-MD5"); !errors.Is(err, processors.ErrUnsupportedHmacAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedHmacAlgorithm, got: %v", err)
+	}
+}
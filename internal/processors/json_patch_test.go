@@ -0,0 +1,85 @@
+package processors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"laba6/internal/processors"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, actual []byte, expected string) {
+	t.Helper()
+	var a, e any
+	if err := json.Unmarshal(actual, &a); err != nil {
+		t.Fatalf("Result is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatalf("Expected value is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`
+	patch := `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`
+
+	result, err := processors.ApplyMergePatch([]byte(doc), []byte(patch))
+	if err != nil {
+		t.Fatalf("ApplyMergePatch failed: %v", err)
+	}
+	assertJSONEqual(t, result, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`)
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	cases := []struct {
+		doc, patch, expected string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"test","path":"/a~1b","value":1},{"op":"remove","path":"/m~0n"}]`, `{"a/b":1}`},
+		{`{"foo":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, c := range cases {
+		result, err := processors.ApplyJSONPatch([]byte(c.doc), []byte(c.patch))
+		if err != nil {
+			t.Errorf("ApplyJSONPatch(%s) failed: %v", c.patch, err)
+			continue
+		}
+		assertJSONEqual(t, result, c.expected)
+	}
+}
+
+func TestApplyJSONPatch_Errors(t *testing.T) {
+	invalid := []string{
+		`{"op":"add","path":"/a","value":1}`,
+		`[{"op":"add","path":"/missing/child","value":1}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"add","path":"/list/01","value":1}]`,
+		`[{"op":"add","path":"/list/5","value":1}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"move","from":"/obj","path":"/obj/child"}]`,
+		`[{"op":"increment","path":"/a"}]`,
+		`[{"op":"add","path":"a","value":1}]`,
+	}
+	for _, patch := range invalid {
+		if _, err := processors.ApplyJSONPatch([]byte(`{"list":[1],"obj":{}}`), []byte(patch)); !errors.Is(err, processors.ErrInvalidPatch) {
+			t.Errorf("Expected ErrInvalidPatch for %s, got: %v", patch, err)
+		}
+	}
+
+	patch := `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`
+	if _, err := processors.ApplyJSONPatch([]byte(`{"a":1}`), []byte(patch)); !errors.Is(err, processors.ErrPatchTestFailed) {
+		t.Errorf("Expected ErrPatchTestFailed, got: %v", err)
+	}
+}
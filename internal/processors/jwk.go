@@ -0,0 +1,259 @@
+package processors
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"laba6/internal/models"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// PublicKeyToJWK converts the public half of a stored key pair into a JWK.
+// The key ID is the stored key pair ID. EC and Ed25519 keys are published with
+// use "sig"; X25519 keys can only be used for key agreement and get use "enc".
+// RSA keys serve both signing (RS*/PS*) and OAEP encryption, so their JWKs
+// carry neither use nor alg.
+func PublicKeyToJWK(pair models.KeyPair) (models.JWK, error) {
+	block, _ := pem.Decode([]byte(pair.PublicKey))
+	if block == nil {
+		return models.JWK{}, fmt.Errorf("failed to decode public key PEM block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return models.JWK{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	jwk, err := publicJWK(key)
+	if err != nil {
+		return models.JWK{}, err
+	}
+	jwk.Kid = strconv.Itoa(pair.ID)
+	return jwk, nil
+}
+
+func publicJWK(key any) (models.JWK, error) {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return models.JWK{
+			Kty: "RSA",
+			N:   b64url(pub.N.Bytes()),
+			E:   b64url(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return models.JWK{}, fmt.Errorf("invalid EC public key: %w", err)
+		}
+		// Uncompressed point: 0x04 || X || Y, each coordinate padded to the field size
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		crv, alg := "P-256", "ES256"
+		if pub.Curve.Params().BitSize == 384 {
+			crv, alg = "P-384", "ES384"
+		}
+		return models.JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: alg,
+			Crv: crv,
+			X:   b64url(point[1 : 1+size]),
+			Y:   b64url(point[1+size:]),
+		}, nil
+	case ed25519.PublicKey:
+		return models.JWK{Kty: "OKP", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: b64url(pub)}, nil
+	case *ecdh.PublicKey:
+		if pub.Curve() != ecdh.X25519() {
+			return models.JWK{}, fmt.Errorf("%w: unsupported ECDH curve", ErrUnsupportedKeyAlgorithm)
+		}
+		return models.JWK{Kty: "OKP", Use: "enc", Alg: "ECDH-ES", Crv: "X25519", X: b64url(pub.Bytes())}, nil
+	default:
+		return models.JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedKeyAlgorithm, key)
+	}
+}
+
+// parseJWK parses an RSA, EC (P-256/P-384) or OKP (Ed25519/X25519) JWK into a
+// private key when "d" is present and into a public key otherwise.
+func parseJWK(data []byte) (any, error) {
+	var jwk models.JWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWK: %v", ErrInvalidKeyData, err)
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		return parseRsaJWK(jwk)
+	case "EC":
+		return parseEcJWK(jwk)
+	case "OKP":
+		return parseOkpJWK(jwk)
+	default:
+		return nil, fmt.Errorf("%w: JWK key type %q", ErrUnsupportedKeyAlgorithm, jwk.Kty)
+	}
+}
+
+func parseRsaJWK(jwk models.JWK) (any, error) {
+	n, err := jwkInt(jwk.N, "n")
+	if err != nil {
+		return nil, err
+	}
+	e, err := jwkInt(jwk.E, "e")
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("%w: invalid RSA public exponent", ErrInvalidKeyData)
+	}
+	pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if jwk.D == "" {
+		return pub, nil
+	}
+
+	if jwk.P == "" || jwk.Q == "" {
+		return nil, fmt.Errorf("%w: RSA private JWKs must include the primes p and q", ErrInvalidKeyData)
+	}
+	d, err := jwkInt(jwk.D, "d")
+	if err != nil {
+		return nil, err
+	}
+	p, err := jwkInt(jwk.P, "p")
+	if err != nil {
+		return nil, err
+	}
+	q, err := jwkInt(jwk.Q, "q")
+	if err != nil {
+		return nil, err
+	}
+
+	priv := &rsa.PrivateKey{PublicKey: *pub, D: d, Primes: []*big.Int{p, q}}
+	if err := priv.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyData, err)
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func parseEcJWK(jwk models.JWK) (any, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	default:
+		return nil, fmt.Errorf("%w: JWK curve %q", ErrUnsupportedKeyAlgorithm, jwk.Crv)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+
+	x, err := jwkBytes(jwk.X, "x", size)
+	if err != nil {
+		return nil, err
+	}
+	y, err := jwkBytes(jwk.Y, "y", size)
+	if err != nil {
+		return nil, err
+	}
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("%w: invalid EC point: %v", ErrInvalidKeyData, err)
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if jwk.D == "" {
+		return pub, nil
+	}
+
+	d, err := jwkBytes(jwk.D, "d", size)
+	if err != nil {
+		return nil, err
+	}
+	ecdhKey, err := ecdhCurve.NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid EC private key: %v", ErrInvalidKeyData, err)
+	}
+	if !bytes.Equal(ecdhKey.PublicKey().Bytes(), point) {
+		return nil, fmt.Errorf("%w: EC private key does not match x and y", ErrInvalidKeyData)
+	}
+	return &ecdsa.PrivateKey{PublicKey: *pub, D: new(big.Int).SetBytes(d)}, nil
+}
+
+func parseOkpJWK(jwk models.JWK) (any, error) {
+	x, err := jwkBytes(jwk.X, "x", 32)
+	if err != nil {
+		return nil, err
+	}
+
+	switch jwk.Crv {
+	case "Ed25519":
+		if jwk.D == "" {
+			return ed25519.PublicKey(x), nil
+		}
+		seed, err := jwkBytes(jwk.D, "d", ed25519.SeedSize)
+		if err != nil {
+			return nil, err
+		}
+		priv := ed25519.NewKeyFromSeed(seed)
+		if !bytes.Equal(priv.Public().(ed25519.PublicKey), x) {
+			return nil, fmt.Errorf("%w: Ed25519 private key does not match x", ErrInvalidKeyData)
+		}
+		return priv, nil
+	case "X25519":
+		if jwk.D == "" {
+			pub, err := ecdh.X25519().NewPublicKey(x)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidKeyData, err)
+			}
+			return pub, nil
+		}
+		d, err := jwkBytes(jwk.D, "d", 32)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(d)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyData, err)
+		}
+		if !bytes.Equal(priv.PublicKey().Bytes(), x) {
+			return nil, fmt.Errorf("%w: X25519 private key does not match x", ErrInvalidKeyData)
+		}
+		return priv, nil
+	default:
+		return nil, fmt.Errorf("%w: JWK curve %q", ErrUnsupportedKeyAlgorithm, jwk.Crv)
+	}
+}
+
+// jwkBytes decodes a base64url JWK member that must be exactly size bytes long.
+func jwkBytes(value, name string, size int) ([]byte, error) {
+	data, err := b64urlDecode(value)
+	if err != nil || len(data) != size {
+		return nil, fmt.Errorf("%w: JWK member %q must be %d base64url encoded bytes", ErrInvalidKeyData, name, size)
+	}
+	return data, nil
+}
+
+// jwkInt decodes a base64url big-endian integer JWK member.
+func jwkInt(value, name string) (*big.Int, error) {
+	data, err := b64urlDecode(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("%w: JWK member %q is missing or not base64url", ErrInvalidKeyData, name)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func b64url(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// b64urlDecode accepts base64url with or without padding.
+func b64urlDecode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,78 @@
+package processors_test
+
+import (
+	"encoding/base64"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+func TestPublicKeyToJWK(t *testing.T) {
+	rsaKeys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate RSA keys: %v", err)
+	}
+	p256, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate P-256 keys: %v", err)
+	}
+	p384, err := ecService.GenerateKeyPair(models.KeyAlgorithmECDSAP384)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate P-384 keys: %v", err)
+	}
+	ed, err := ecService.GenerateKeyPair(models.KeyAlgorithmEd25519)
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate Ed25519 keys: %v", err)
+	}
+	x, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate X25519 keys: %v", err)
+	}
+
+	cases := []struct {
+		pair     models.KeyPair
+		kty      string
+		alg      string
+		use      string
+		crv      string
+		coordLen int
+	}{
+		{models.KeyPair{ID: 1, PublicKey: rsaKeys.PublicKey}, "RSA", "", "", "", 0},
+		{models.KeyPair{ID: 2, PublicKey: p256.PublicKey}, "EC", "ES256", "sig", "P-256", 32},
+		{models.KeyPair{ID: 3, PublicKey: p384.PublicKey}, "EC", "ES384", "sig", "P-384", 48},
+		{models.KeyPair{ID: 4, PublicKey: ed.PublicKey}, "OKP", "EdDSA", "sig", "Ed25519", 32},
+		{models.KeyPair{ID: 5, PublicKey: x.PublicKey}, "OKP", "ECDH-ES", "enc", "X25519", 32},
+	}
+
+	for _, tc := range cases {
+		jwk, err := processors.PublicKeyToJWK(tc.pair)
+		if err != nil {
+			t.Fatalf("PublicKeyToJWK(%s) failed: %v", tc.kty, err)
+		}
+
+		if jwk.Kty != tc.kty || jwk.Alg != tc.alg || jwk.Use != tc.use || jwk.Crv != tc.crv {
+			t.Errorf("Unexpected JWK header for %s: %+v", tc.kty, jwk)
+		}
+		if jwk.Kid == "" {
+			t.Errorf("JWK for %s has no kid", tc.kty)
+		}
+
+		if tc.kty == "RSA" {
+			if jwk.E != "AQAB" || jwk.N == "" {
+				t.Errorf("Unexpected RSA members: n=%q e=%q", jwk.N, jwk.E)
+			}
+			continue
+		}
+
+		xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(xBytes) != tc.coordLen {
+			t.Errorf("Expected %d byte x coordinate for %s, got %d (%v)", tc.coordLen, tc.alg, len(xBytes), err)
+		}
+		if tc.kty == "EC" {
+			yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if err != nil || len(yBytes) != tc.coordLen {
+				t.Errorf("Expected %d byte y coordinate for %s, got %d (%v)", tc.coordLen, tc.alg, len(yBytes), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package processors
+
+import (
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"laba6/internal/models"
+)
+
+// DefaultPBKDF2Iterations is used when a PBKDF2 request does not set an iteration
+// count; it is the OWASP recommendation for PBKDF2-HMAC-SHA256.
+const DefaultPBKDF2Iterations = 600_000
+
+const (
+	// minDerivedKeyLength is the shortest raw output when no target algorithm is given.
+	minDerivedKeyLength = 16
+	// maxPBKDF2KeyLength bounds raw PBKDF2 output.
+	maxPBKDF2KeyLength = 1024
+	// minHkdfSecretLength keeps HKDF from being used as a password hash; use PBKDF2 for passwords.
+	minHkdfSecretLength = 16
+	// minPBKDF2Iterations follows the OWASP floor for PBKDF2-HMAC-SHA512.
+	minPBKDF2Iterations = 210_000
+	// minPBKDF2SaltLength is the NIST SP 800-132 minimum salt length.
+	minPBKDF2SaltLength = 16
+	// kdfMasterKeyLength is the size of generated master keys.
+	kdfMasterKeyLength = 32
+)
+
+// kdfMasterKeySalt is the fixed HKDF salt for stored master keys. It separates
+// keys derived from a master key from any other use of the same bytes.
+var kdfMasterKeySalt = []byte("laba6/kdf-master-key/v1")
+
+// ErrInvalidKdfParameters is returned for derivation parameters that are out of range.
+var ErrInvalidKdfParameters = errors.New("invalid key derivation parameters")
+
+var derivedKeyLengths = map[models.DerivedKeyTarget]int{
+	models.DerivedKeyTargetAES128:           16,
+	models.DerivedKeyTargetAES192:           24,
+	models.DerivedKeyTargetAES256:           32,
+	models.DerivedKeyTargetChaCha20Poly1305: 32,
+	models.DerivedKeyTargetHMACSHA256:       32,
+	models.DerivedKeyTargetHMACSHA512:       64,
+}
+
+// IKdfService derives keys with HKDF (RFC 5869) and PBKDF2 (RFC 8018).
+type IKdfService interface {
+	HKDF(mode models.HkdfMode, hashName models.KdfHash, secret, salt []byte, info string, target models.DerivedKeyTarget, length int) ([]byte, error)
+	PBKDF2(hashName models.KdfHash, password string, salt []byte, iterations int, target models.DerivedKeyTarget, length int) ([]byte, error)
+	GenerateMasterKey() ([]byte, error)
+	DeriveFromMasterKey(hashName models.KdfHash, master []byte, info string, target models.DerivedKeyTarget, length int) ([]byte, error)
+}
+
+type KdfService struct{}
+
+func NewKdfService() *KdfService {
+	return &KdfService{}
+}
+
+// HKDF runs extract, expand or both. The extract step returns a pseudorandom key
+// as long as the hash; expand takes such a key as its secret. An empty hash is SHA-256.
+func (s *KdfService) HKDF(mode models.HkdfMode, hashName models.KdfHash, secret, salt []byte, info string, target models.DerivedKeyTarget, length int) ([]byte, error) {
+	newHash, err := kdfHash(hashName)
+	if err != nil {
+		return nil, err
+	}
+	hashSize := newHash().Size()
+	if len(secret) < minHkdfSecretLength {
+		return nil, fmt.Errorf("%w: the input key must be at least %d bytes", ErrInvalidKdfParameters, minHkdfSecretLength)
+	}
+
+	if mode == models.HkdfModeExtract {
+		if target != "" || (length != 0 && length != hashSize) {
+			return nil, fmt.Errorf("%w: extract always returns a %d byte pseudorandom key", ErrInvalidKdfParameters, hashSize)
+		}
+		return hkdf.Extract(newHash, secret, salt)
+	}
+
+	length, err = derivedKeyLength(target, length, 255*hashSize)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case models.HkdfModeExtractAndExpand, "":
+		return hkdf.Key(newHash, secret, salt, info, length)
+	case models.HkdfModeExpand:
+		if len(salt) != 0 {
+			return nil, fmt.Errorf("%w: expand does not take a salt", ErrInvalidKdfParameters)
+		}
+		if len(secret) < hashSize {
+			return nil, fmt.Errorf("%w: the pseudorandom key must be at least %d bytes", ErrInvalidKdfParameters, hashSize)
+		}
+		return hkdf.Expand(newHash, secret, info, length)
+	default:
+		return nil, fmt.Errorf("%w: unknown HKDF mode %q", ErrInvalidKdfParameters, mode)
+	}
+}
+
+// GenerateMasterKey returns random material for a new stored KDF master key.
+func (s *KdfService) GenerateMasterKey() ([]byte, error) {
+	key := make([]byte, kdfMasterKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	return key, nil
+}
+
+// DeriveFromMasterKey derives a subkey from a stored master key. It always runs
+// extract and expand with the fixed kdfMasterKeySalt, so callers choose only the
+// info string and never get raw HMAC output under the master key.
+func (s *KdfService) DeriveFromMasterKey(hashName models.KdfHash, master []byte, info string, target models.DerivedKeyTarget, length int) ([]byte, error) {
+	return s.HKDF(models.HkdfModeExtractAndExpand, hashName, master, kdfMasterKeySalt, info, target, length)
+}
+
+// PBKDF2 derives a key from a password. An empty hash is SHA-256.
+func (s *KdfService) PBKDF2(hashName models.KdfHash, password string, salt []byte, iterations int, target models.DerivedKeyTarget, length int) ([]byte, error) {
+	newHash, err := kdfHash(hashName)
+	if err != nil {
+		return nil, err
+	}
+	if password == "" {
+		return nil, fmt.Errorf("%w: password is required", ErrInvalidKdfParameters)
+	}
+	if len(salt) < minPBKDF2SaltLength {
+		return nil, fmt.Errorf("%w: the salt must be at least %d bytes", ErrInvalidKdfParameters, minPBKDF2SaltLength)
+	}
+	if iterations < minPBKDF2Iterations || iterations > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: iterations must be between %d and %d", ErrInvalidKdfParameters, minPBKDF2Iterations, maxPBKDF2Iterations)
+	}
+	length, err = derivedKeyLength(target, length, maxPBKDF2KeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return pbkdf2.Key(newHash, password, salt, iterations, length)
+}
+
+// derivedKeyLength resolves the output length: a target algorithm fixes it, otherwise
+// the requested length must lie between minDerivedKeyLength and max.
+func derivedKeyLength(target models.DerivedKeyTarget, length, max int) (int, error) {
+	if target != "" {
+		want, ok := derivedKeyLengths[target]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown target algorithm %q", ErrInvalidKdfParameters, target)
+		}
+		if length != 0 && length != want {
+			return 0, fmt.Errorf("%w: %s keys are %d bytes, not %d", ErrInvalidKdfParameters, target, want, length)
+		}
+		return want, nil
+	}
+	if length < minDerivedKeyLength || length > max {
+		return 0, fmt.Errorf("%w: length must be between %d and %d bytes", ErrInvalidKdfParameters, minDerivedKeyLength, max)
+	}
+	return length, nil
+}
+
+func kdfHash(name models.KdfHash) (func() hash.Hash, error) {
+	switch name {
+	case models.KdfHashSHA256, "":
+		return sha256.New, nil
+	case models.KdfHashSHA384:
+		return sha512.New384, nil
+	case models.KdfHashSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported hash %q", ErrInvalidKdfParameters, name)
+	}
+}
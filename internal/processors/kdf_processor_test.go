@@ -0,0 +1,128 @@
+package processors_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var kdfService = processors.NewKdfService()
+
+// RFC 5869 test case 1.
+func TestKdfService_HKDFKnownAnswer(t *testing.T) {
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+
+	prk, err := kdfService.HKDF(models.HkdfModeExtract, models.KdfHashSHA256, ikm, salt, "", "", 0)
+	if err != nil {
+		t.Fatalf("HKDF extract failed: %v", err)
+	}
+	if got := hex.EncodeToString(prk); got != "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5" {
+		t.Errorf("Unexpected PRK: %s", got)
+	}
+
+	const okm = "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+	key, err := kdfService.HKDF("", "", ikm, salt, string(info), "", 42)
+	if err != nil {
+		t.Fatalf("HKDF failed: %v", err)
+	}
+	if got := hex.EncodeToString(key); got != okm {
+		t.Errorf("Unexpected OKM: %s", got)
+	}
+
+	expanded, err := kdfService.HKDF(models.HkdfModeExpand, models.KdfHashSHA256, prk, nil, string(info), "", 42)
+	if err != nil {
+		t.Fatalf("HKDF expand failed: %v", err)
+	}
+	if hex.EncodeToString(expanded) != okm {
+		t.Error("Extract then expand should match the combined derivation")
+	}
+}
+
+func TestKdfService_TargetLengths(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 32)
+
+	key, err := kdfService.HKDF("", models.KdfHashSHA512, secret, nil, "webhooks", models.DerivedKeyTargetHMACSHA512, 0)
+	if err != nil || len(key) != 64 {
+		t.Fatalf("Expected a 64 byte HMAC-SHA512 key, got %d bytes: %v", len(key), err)
+	}
+
+	other, _ := kdfService.HKDF("", models.KdfHashSHA512, secret, nil, "payments", models.DerivedKeyTargetHMACSHA512, 0)
+	if bytes.Equal(key, other) {
+		t.Error("Different info strings must derive different keys")
+	}
+
+	invalid := map[string]func() ([]byte, error){
+		"length mismatch": func() ([]byte, error) {
+			return kdfService.HKDF("", "", secret, nil, "", models.DerivedKeyTargetAES256, 16)
+		},
+		"unknown target": func() ([]byte, error) {
+			return kdfService.HKDF("", "", secret, nil, "", "DES", 0)
+		},
+		"short raw output": func() ([]byte, error) {
+			return kdfService.HKDF("", "", secret, nil, "", "", 8)
+		},
+		"short input key": func() ([]byte, error) {
+			return kdfService.HKDF("", "", []byte("password"), nil, "", models.DerivedKeyTargetAES128, 0)
+		},
+		"HKDF output limit": func() ([]byte, error) {
+			return kdfService.HKDF("", models.KdfHashSHA256, secret, nil, "", "", 255*32+1)
+		},
+	}
+	for name, derive := range invalid {
+		if _, err := derive(); !errors.Is(err, processors.ErrInvalidKdfParameters) {
+			t.Errorf("%s: expected ErrInvalidKdfParameters, got: %v", name, err)
+		}
+	}
+}
+
+func TestKdfService_PBKDF2(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+
+	key, err := kdfService.PBKDF2("", "hunter22", salt, processors.DefaultPBKDF2Iterations, models.DerivedKeyTargetAES256, 0)
+	if err != nil || len(key) != 32 {
+		t.Fatalf("Expected a 32 byte AES-256 key, got %d bytes: %v", len(key), err)
+	}
+	again, _ := kdfService.PBKDF2(models.KdfHashSHA256, "hunter22", salt, processors.DefaultPBKDF2Iterations, "", 32)
+	if !bytes.Equal(key, again) {
+		t.Error("PBKDF2 should be deterministic for the same inputs")
+	}
+
+	if _, err := kdfService.PBKDF2("", "hunter22", salt, 1000, models.DerivedKeyTargetAES256, 0); !errors.Is(err, processors.ErrInvalidKdfParameters) {
+		t.Errorf("Expected ErrInvalidKdfParameters for too few iterations, got: %v", err)
+	}
+	if _, err := kdfService.PBKDF2("", "hunter22", []byte("short"), processors.DefaultPBKDF2Iterations, "", 32); !errors.Is(err, processors.ErrInvalidKdfParameters) {
+		t.Errorf("Expected ErrInvalidKdfParameters for a short salt, got: %v", err)
+	}
+}
+
+func TestKdfService_DeriveFromMasterKey(t *testing.T) {
+	master, err := kdfService.GenerateMasterKey()
+	if err != nil || len(master) != 32 {
+		t.Fatalf("Expected a 32 byte master key, got %d bytes: %v", len(master), err)
+	}
+
+	key, err := kdfService.DeriveFromMasterKey("", master, "webhooks", models.DerivedKeyTargetHMACSHA256, 0)
+	if err != nil {
+		t.Fatalf("DeriveFromMasterKey failed: %v", err)
+	}
+
+	// HKDF-Expand alone would return HMAC(master, info || 0x01), a MAC anyone could
+	// verify under the master key.
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("webhooks\x01"))
+	if bytes.Equal(key, mac.Sum(nil)) {
+		t.Error("A master key derivation must not return a MAC under the master key")
+	}
+
+	again, _ := kdfService.DeriveFromMasterKey("", master, "webhooks", models.DerivedKeyTargetHMACSHA256, 0)
+	if !bytes.Equal(key, again) {
+		t.Error("Master key derivation must be deterministic")
+	}
+}
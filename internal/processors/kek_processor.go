@@ -0,0 +1,106 @@
+package processors
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// LegacyKekVersion marks key material stored before envelope encryption was
+// introduced; such values are kept as plaintext and returned unchanged by Unwrap.
+const LegacyKekVersion = 0
+
+// KekService wraps key material with a master key-encryption key (KEK) using
+// AES-256-GCM. Several KEK versions may be loaded so that material wrapped with
+// a previous KEK can still be unwrapped during rotation; new material is always
+// wrapped with the current version.
+type KekService struct {
+	currentVersion int
+	keks           map[int]cipher.AEAD
+}
+
+// NewKekService creates a KekService from a version -> 32-byte KEK map.
+func NewKekService(currentVersion int, keks map[int][]byte) (*KekService, error) {
+	if _, ok := keks[currentVersion]; !ok {
+		return nil, fmt.Errorf("current KEK version %d is not configured", currentVersion)
+	}
+
+	aeads := make(map[int]cipher.AEAD, len(keks))
+	for version, key := range keks {
+		if version == LegacyKekVersion {
+			return nil, fmt.Errorf("KEK version %d is reserved for legacy plaintext rows", LegacyKekVersion)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("KEK version %d must be 32 bytes, got %d", version, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AES cipher for KEK version %d: %w", version, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM for KEK version %d: %w", version, err)
+		}
+		aeads[version] = gcm
+	}
+
+	return &KekService{currentVersion: currentVersion, keks: aeads}, nil
+}
+
+// CurrentVersion returns the KEK version used for new wraps.
+func (s *KekService) CurrentVersion() int {
+	return s.currentVersion
+}
+
+// Wrap encrypts key material with the current KEK and returns it base64 encoded
+// together with the KEK version that has to be stored next to it. The binding
+// names the record the value is stored in (see Unwrap).
+func (s *KekService) Wrap(plaintext []byte, binding string) (string, int, error) {
+	gcm := s.keks[s.currentVersion]
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", 0, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	wrapped := gcm.Seal(nonce, nonce, plaintext, kekAssociatedData(s.currentVersion, binding))
+	return base64.StdEncoding.EncodeToString(wrapped), s.currentVersion, nil
+}
+
+// Unwrap decrypts key material previously returned by Wrap with the given KEK
+// version. The binding must match the one given to Wrap, so a wrapped value
+// copied into another record does not unwrap.
+func (s *KekService) Unwrap(wrapped string, version int, binding string) ([]byte, error) {
+	if version == LegacyKekVersion {
+		return []byte(wrapped), nil
+	}
+
+	gcm, ok := s.keks[version]
+	if !ok {
+		return nil, fmt.Errorf("KEK version %d is not configured", version)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped key: %w", err)
+	}
+	if len(raw) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], kekAssociatedData(version, binding))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key with KEK version %d: %w", version, err)
+	}
+	return plaintext, nil
+}
+
+// kekAssociatedData binds a wrapped value to the KEK version it was produced with
+// and to the record it is stored in.
+func kekAssociatedData(version int, binding string) []byte {
+	return []byte(fmt.Sprintf("laba6-kek-v%d:%s", version, binding))
+}
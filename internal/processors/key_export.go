@@ -0,0 +1,117 @@
+package processors
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"laba6/internal/models"
+	"strconv"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// minExportPassphraseLength is the shortest passphrase accepted for private key export.
+const minExportPassphraseLength = 8
+
+// IKeyExportService converts stored key pairs into portable formats.
+type IKeyExportService interface {
+	ExportPublicKey(pair models.KeyPair, format models.KeyFormat, pkcs1 bool) (any, error)
+	ExportPrivateKey(pair models.KeyPair, format models.KeyFormat, passphrase string) (string, error)
+}
+
+type KeyExportService struct{}
+
+func NewKeyExportService() *KeyExportService {
+	return &KeyExportService{}
+}
+
+// ExportPublicKey returns the public key as PKIX (or PKCS#1 for RSA) PEM, base64
+// DER, an OpenSSH authorized_keys line or a JWK object.
+func (s *KeyExportService) ExportPublicKey(pair models.KeyPair, format models.KeyFormat, pkcs1 bool) (any, error) {
+	block, _ := pem.Decode([]byte(pair.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	if pkcs1 {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: PKCS#1 is only defined for RSA keys", ErrUnsupportedKeyAlgorithm)
+		}
+		block = &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(rsaKey)}
+	}
+
+	switch format {
+	case models.KeyFormatPEM, "":
+		return string(pem.EncodeToMemory(block)), nil
+	case models.KeyFormatDER:
+		return base64.StdEncoding.EncodeToString(block.Bytes), nil
+	case models.KeyFormatJWK:
+		jwk, err := publicJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Kid = strconv.Itoa(pair.ID)
+		return jwk, nil
+	case models.KeyFormatOpenSSH:
+		sshKey, err := ssh.NewPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s keys have no OpenSSH encoding", ErrUnsupportedKeyAlgorithm, pair.Algorithm)
+		}
+		return string(ssh.MarshalAuthorizedKey(sshKey)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyFormat, format)
+	}
+}
+
+// ExportPrivateKey returns the private key as PBES2 encrypted PKCS#8, PEM
+// ("ENCRYPTED PRIVATE KEY") or base64 DER. Unencrypted private keys are never exported.
+func (s *KeyExportService) ExportPrivateKey(pair models.KeyPair, format models.KeyFormat, passphrase string) (string, error) {
+	if len(passphrase) < minExportPassphraseLength {
+		return "", fmt.Errorf("%w: at least %d characters are required", ErrKeyPassphrase, minExportPassphraseLength)
+	}
+	if format != "" && format != models.KeyFormatPEM && format != models.KeyFormatDER {
+		return "", fmt.Errorf("%w: private keys can only be exported as pem or der", ErrUnsupportedKeyFormat)
+	}
+
+	privateDER, err := privateKeyPKCS8(pair.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := encryptPKCS8(privateDER, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	if format == models.KeyFormatDER {
+		return base64.StdEncoding.EncodeToString(encrypted), nil
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted})), nil
+}
+
+// privateKeyPKCS8 converts a stored PKCS#1 or PKCS#8 private key PEM to PKCS#8 DER.
+func privateKeyPKCS8(privateKeyPEM string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM block")
+	}
+	if block.Type != "RSA PRIVATE KEY" {
+		return block.Bytes, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return der, nil
+}
@@ -0,0 +1,240 @@
+package processors
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"strings"
+)
+
+var (
+	// ErrInvalidKeyData is returned for key material that cannot be parsed.
+	ErrInvalidKeyData = errors.New("invalid key data")
+	// ErrUnsupportedKeyFormat is returned for an unknown import or export format.
+	ErrUnsupportedKeyFormat = errors.New("unsupported key format")
+	// ErrKeyPassphrase is returned when an encrypted key has no or the wrong passphrase.
+	ErrKeyPassphrase = errors.New("missing or incorrect key passphrase")
+)
+
+// IKeyImportService parses externally generated keys into storable key pairs.
+type IKeyImportService interface {
+	ImportKey(data string, format models.KeyFormat, passphrase string) (models.KeyPair, error)
+}
+
+// KeyImportService accepts PKCS#1, PKCS#8 (plain or PBES2 encrypted), SEC 1 EC,
+// PKIX public keys as PEM or base64 DER, and JWKs. Keys are normalized to the
+// encodings used for generated keys and RSA keys are checked against the policy.
+type KeyImportService struct {
+	rsaPolicy RsaKeyPolicy
+}
+
+func NewKeyImportService(rsaPolicy RsaKeyPolicy) *KeyImportService {
+	return &KeyImportService{rsaPolicy: rsaPolicy}
+}
+
+// ImportKey parses a private or public key. An empty format is detected from the
+// data. Public-only imports return a key pair without a private key.
+func (s *KeyImportService) ImportKey(data string, format models.KeyFormat, passphrase string) (models.KeyPair, error) {
+	data = strings.TrimSpace(data)
+	if format == "" {
+		format = detectKeyFormat(data)
+	}
+
+	var key any
+	var err error
+	switch format {
+	case models.KeyFormatPEM:
+		key, err = parsePEMKey(data, passphrase)
+	case models.KeyFormatDER:
+		der, decodeErr := base64.StdEncoding.DecodeString(data)
+		if decodeErr != nil {
+			return models.KeyPair{}, fmt.Errorf("%w: DER keys must be base64 encoded: %v", ErrInvalidKeyData, decodeErr)
+		}
+		key, err = parseDERKey(der, passphrase)
+	case models.KeyFormatJWK:
+		key, err = parseJWK([]byte(data))
+	default:
+		return models.KeyPair{}, fmt.Errorf("%w: %q cannot be imported", ErrUnsupportedKeyFormat, format)
+	}
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+
+	return s.normalizeKey(key)
+}
+
+func detectKeyFormat(data string) models.KeyFormat {
+	switch {
+	case strings.HasPrefix(data, "-----BEGIN"):
+		return models.KeyFormatPEM
+	case strings.HasPrefix(data, "{"):
+		return models.KeyFormatJWK
+	default:
+		return models.KeyFormatDER
+	}
+}
+
+func parsePEMKey(data, passphrase string) (any, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode PEM block", ErrInvalidKeyData)
+	}
+	if _, ok := block.Headers["DEK-Info"]; ok {
+		return nil, fmt.Errorf("%w: legacy encrypted PEM is not supported, convert it to encrypted PKCS#8", ErrInvalidKeyData)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		return parseEncryptedPKCS8(block.Bytes, passphrase)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM block type %q", ErrInvalidKeyData, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyData, err)
+	}
+	return key, nil
+}
+
+// parseDERKey tries every supported DER structure in turn.
+func parseDERKey(der []byte, passphrase string) (any, error) {
+	var encrypted encryptedPrivateKeyInfo
+	if unmarshalDER(der, &encrypted) == nil && encrypted.Algorithm.Algorithm.Equal(oidPBES2) {
+		return parseEncryptedPKCS8(der, passphrase)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: DER data is not a supported private or public key", ErrInvalidKeyData)
+}
+
+func parseEncryptedPKCS8(der []byte, passphrase string) (any, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: the key is encrypted", ErrKeyPassphrase)
+	}
+	plain, err := decryptPKCS8(der, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		// Padding checks let roughly 1 in 256 wrong passphrases through.
+		return nil, ErrKeyPassphrase
+	}
+	return key, nil
+}
+
+// normalizeKey validates a parsed key and encodes it like a generated one:
+// PKCS#1 for RSA private keys, PKCS#8 for other private keys and PKIX for public keys.
+func (s *KeyImportService) normalizeKey(key any) (models.KeyPair, error) {
+	publicKey := key
+	signer, isPrivate := key.(interface{ Public() crypto.PublicKey })
+	if isPrivate {
+		publicKey = signer.Public()
+	}
+
+	algorithm, keySize, err := s.describePublicKey(publicKey)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+	publicPEM, err := encodePublicKeyPEM(publicKey)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+	pair := models.KeyPair{Algorithm: algorithm, KeySize: keySize, PublicKey: publicPEM}
+	if !isPrivate {
+		return pair, nil
+	}
+
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		if err := rsaKey.Validate(); err != nil {
+			return models.KeyPair{}, fmt.Errorf("%w: %v", ErrInvalidKeyData, err)
+		}
+		pair.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+		return pair, nil
+	}
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	pair.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes}))
+	return pair, nil
+}
+
+// describePublicKey maps a public key to its stored algorithm and size and
+// rejects curves and key sizes the service does not support.
+func (s *KeyImportService) describePublicKey(key any) (models.KeyAlgorithm, int, error) {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if err := s.rsaPolicy.checkPublicKey(pub); err != nil {
+			return "", 0, err
+		}
+		return models.KeyAlgorithmRSA, pub.N.BitLen(), nil
+	case *ecdsa.PublicKey:
+		if _, err := pub.ECDH(); err != nil {
+			return "", 0, fmt.Errorf("%w: invalid EC public key: %v", ErrInvalidKeyData, err)
+		}
+		switch pub.Curve {
+		case elliptic.P256():
+			return models.KeyAlgorithmECDSAP256, 256, nil
+		case elliptic.P384():
+			return models.KeyAlgorithmECDSAP384, 384, nil
+		default:
+			return "", 0, fmt.Errorf("%w: curve %s", ErrUnsupportedKeyAlgorithm, pub.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		if len(pub) != ed25519.PublicKeySize {
+			return "", 0, fmt.Errorf("%w: invalid Ed25519 public key length", ErrInvalidKeyData)
+		}
+		return models.KeyAlgorithmEd25519, 256, nil
+	case *ecdh.PublicKey:
+		if pub.Curve() != ecdh.X25519() {
+			return "", 0, fmt.Errorf("%w: unsupported ECDH curve", ErrUnsupportedKeyAlgorithm)
+		}
+		return models.KeyAlgorithmX25519, 256, nil
+	default:
+		return "", 0, fmt.Errorf("%w: %T", ErrUnsupportedKeyAlgorithm, key)
+	}
+}
+
+func encodePublicKeyPEM(publicKey crypto.PublicKey) (string, error) {
+	publicBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})), nil
+}
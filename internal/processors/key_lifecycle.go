@@ -0,0 +1,70 @@
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"time"
+)
+
+var (
+	// ErrKeyNotUsable is returned when a key's status or validity window forbids an operation.
+	ErrKeyNotUsable = errors.New("key is not usable for this operation")
+	// ErrInvalidKeyTransition is returned for a lifecycle change that is not allowed.
+	ErrInvalidKeyTransition = errors.New("invalid key status transition")
+)
+
+// keyStatusTransitions lists the statuses each status may move to.
+// Revocation and destruction are final: a revoked key can only be destroyed.
+var keyStatusTransitions = map[models.KeyStatus][]models.KeyStatus{
+	models.KeyStatusPending: {models.KeyStatusActive, models.KeyStatusRevoked, models.KeyStatusDestroyed},
+	models.KeyStatusActive:  {models.KeyStatusRetired, models.KeyStatusRevoked, models.KeyStatusDestroyed},
+	models.KeyStatusRetired: {models.KeyStatusRevoked, models.KeyStatusDestroyed},
+	models.KeyStatusRevoked: {models.KeyStatusDestroyed},
+}
+
+// CheckKeyTransition validates a lifecycle status change.
+func CheckKeyTransition(from, to models.KeyStatus) error {
+	for _, allowed := range keyStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidKeyTransition, from, to)
+}
+
+// CheckAesKeyUsage rejects stored AES keys that are not active. Disabled keys can
+// neither encrypt nor decrypt; only their metadata stays available.
+func CheckAesKeyUsage(meta models.AesKeyMetadata) error {
+	if meta.Status != models.AesKeyStatusActive {
+		return fmt.Errorf("%w: AES key %s is %s", ErrKeyNotUsable, meta.KeyID, meta.Status)
+	}
+	return nil
+}
+
+// CheckKeyUsage enforces the key lifecycle for an operation:
+// encrypt and sign need an active key inside its not_before/expires_at window;
+// decrypt and verify are also allowed for retired or expired keys so existing
+// data stays readable; pending, revoked and destroyed keys are rejected.
+func CheckKeyUsage(pair models.KeyPair, operation models.KeyOperation, now time.Time) error {
+	switch operation {
+	case models.KeyOperationEncrypt, models.KeyOperationSign:
+		if pair.Status != models.KeyStatusActive {
+			return fmt.Errorf("%w: key %d is %s and cannot %s", ErrKeyNotUsable, pair.ID, pair.Status, operation)
+		}
+		if pair.NotBefore != nil && now.Before(*pair.NotBefore) {
+			return fmt.Errorf("%w: key %d is not valid before %s", ErrKeyNotUsable, pair.ID, pair.NotBefore.Format(time.RFC3339))
+		}
+		if pair.ExpiresAt != nil && !now.Before(*pair.ExpiresAt) {
+			return fmt.Errorf("%w: key %d expired at %s", ErrKeyNotUsable, pair.ID, pair.ExpiresAt.Format(time.RFC3339))
+		}
+		return nil
+	case models.KeyOperationDecrypt, models.KeyOperationVerify:
+		if pair.Status != models.KeyStatusActive && pair.Status != models.KeyStatusRetired {
+			return fmt.Errorf("%w: key %d is %s and cannot %s", ErrKeyNotUsable, pair.ID, pair.Status, operation)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrKeyNotUsable, operation)
+	}
+}
@@ -0,0 +1,83 @@
+package processors_test
+
+import (
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+	"time"
+)
+
+func TestCheckKeyUsage(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name      string
+		pair      models.KeyPair
+		operation models.KeyOperation
+		allowed   bool
+	}{
+		{"active sign", models.KeyPair{Status: models.KeyStatusActive}, models.KeyOperationSign, true},
+		{"active encrypt inside window", models.KeyPair{Status: models.KeyStatusActive, NotBefore: &past, ExpiresAt: &future}, models.KeyOperationEncrypt, true},
+		{"active encrypt before not_before", models.KeyPair{Status: models.KeyStatusActive, NotBefore: &future}, models.KeyOperationEncrypt, false},
+		{"expired sign", models.KeyPair{Status: models.KeyStatusActive, ExpiresAt: &past}, models.KeyOperationSign, false},
+		{"expired verify", models.KeyPair{Status: models.KeyStatusActive, ExpiresAt: &past}, models.KeyOperationVerify, true},
+		{"retired encrypt", models.KeyPair{Status: models.KeyStatusRetired}, models.KeyOperationEncrypt, false},
+		{"retired decrypt", models.KeyPair{Status: models.KeyStatusRetired}, models.KeyOperationDecrypt, true},
+		{"revoked verify", models.KeyPair{Status: models.KeyStatusRevoked}, models.KeyOperationVerify, false},
+		{"destroyed decrypt", models.KeyPair{Status: models.KeyStatusDestroyed}, models.KeyOperationDecrypt, false},
+		{"pending sign", models.KeyPair{Status: models.KeyStatusPending}, models.KeyOperationSign, false},
+	}
+
+	for _, tc := range cases {
+		err := processors.CheckKeyUsage(tc.pair, tc.operation, now)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: expected operation to be allowed, got: %v", tc.name, err)
+		}
+		if !tc.allowed && !errors.Is(err, processors.ErrKeyNotUsable) {
+			t.Errorf("%s: expected ErrKeyNotUsable, got: %v", tc.name, err)
+		}
+	}
+}
+
+func TestCheckKeyTransition(t *testing.T) {
+	if err := processors.CheckKeyTransition(models.KeyStatusActive, models.KeyStatusRetired); err != nil {
+		t.Errorf("active -> retired should be allowed: %v", err)
+	}
+	if err := processors.CheckKeyTransition(models.KeyStatusRevoked, models.KeyStatusActive); !errors.Is(err, processors.ErrInvalidKeyTransition) {
+		t.Errorf("revoked -> active should be rejected, got: %v", err)
+	}
+	if err := processors.CheckKeyTransition(models.KeyStatusDestroyed, models.KeyStatusRevoked); !errors.Is(err, processors.ErrInvalidKeyTransition) {
+		t.Errorf("destroyed keys should not change status, got: %v", err)
+	}
+}
+
+func TestCheckAesKeyUsage(t *testing.T) {
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	meta := models.AesKeyMetadata{KeyID: "0123abcd", Status: models.AesKeyStatusActive}
+
+	if err := processors.CheckAesKeyUsage(meta); err != nil {
+		t.Fatalf("Active AES key rejected: %v", err)
+	}
+	ciphertext, err := aesService.Encrypt(aesKey, "", []byte("vault payload"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt with the stored key failed: %v", err)
+	}
+	if plaintext, err := aesService.Decrypt(aesKey, "", ciphertext, nil); err != nil || string(plaintext) != "vault payload" {
+		t.Errorf("Decrypt with the stored key failed: %q, %v", plaintext, err)
+	}
+
+	disabledAt := time.Now()
+	meta.Status, meta.DisabledAt = models.AesKeyStatusDisabled, &disabledAt
+	if err := processors.CheckAesKeyUsage(meta); !errors.Is(err, processors.ErrKeyNotUsable) {
+		t.Errorf("Expected ErrKeyNotUsable for a disabled AES key, got: %v", err)
+	}
+	if err := processors.CheckAesKeyUsage(models.AesKeyMetadata{KeyID: "0123abcd"}); !errors.Is(err, processors.ErrKeyNotUsable) {
+		t.Errorf("Expected ErrKeyNotUsable for an AES key without status, got: %v", err)
+	}
+}
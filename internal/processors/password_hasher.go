@@ -0,0 +1,245 @@
+package processors
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Absolute bounds on hashes and on the policy. Verify additionally rejects hashes
+// that cost more than twice the policy, so a crafted hash cannot make the server
+// spend more memory or CPU than a regular login.
+const (
+	maxPasswordLength   = 1024
+	maxArgon2MemoryKiB  = 256 * 1024
+	maxArgon2Iterations = 16
+	maxArgon2Threads    = 16
+	maxBcryptCost       = 16
+)
+
+var (
+	// ErrInvalidPasswordHash is returned for a hash string that cannot be parsed or is out of bounds.
+	ErrInvalidPasswordHash = errors.New("invalid password hash")
+	// ErrUnsupportedPasswordAlgorithm is returned for an unknown password hashing algorithm.
+	ErrUnsupportedPasswordAlgorithm = errors.New("unsupported password hashing algorithm")
+	// ErrInvalidPassword is returned for a password that cannot be hashed.
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+// Argon2Params are the Argon2id cost parameters (RFC 9106).
+type Argon2Params struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHashPolicy selects the algorithm and cost of new hashes. Hashes made
+// with another algorithm or other parameters are reported as needing a rehash.
+type PasswordHashPolicy struct {
+	Algorithm  models.PasswordAlgorithm
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// DefaultPasswordHashPolicy returns the OWASP recommended Argon2id parameters
+// (19 MiB, 2 iterations, 1 lane) and bcrypt cost 12.
+func DefaultPasswordHashPolicy() PasswordHashPolicy {
+	return PasswordHashPolicy{
+		Algorithm:  models.PasswordAlgorithmArgon2id,
+		Argon2:     Argon2Params{MemoryKiB: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		BcryptCost: 12,
+	}
+}
+
+// Validate checks that the policy is usable and within the verification bounds.
+func (p PasswordHashPolicy) Validate() error {
+	if p.Algorithm != models.PasswordAlgorithmArgon2id && p.Algorithm != models.PasswordAlgorithmBcrypt {
+		return fmt.Errorf("%w: %q", ErrUnsupportedPasswordAlgorithm, p.Algorithm)
+	}
+	a := p.Argon2
+	if a.Parallelism < 1 || a.Parallelism > maxArgon2Threads {
+		return fmt.Errorf("argon2 parallelism must be between 1 and %d", maxArgon2Threads)
+	}
+	if a.MemoryKiB < 8*uint32(a.Parallelism) || a.MemoryKiB > maxArgon2MemoryKiB {
+		return fmt.Errorf("argon2 memory must be between %d and %d KiB", 8*uint32(a.Parallelism), maxArgon2MemoryKiB)
+	}
+	if a.Iterations < 1 || a.Iterations > maxArgon2Iterations {
+		return fmt.Errorf("argon2 iterations must be between 1 and %d", maxArgon2Iterations)
+	}
+	if a.SaltLength < 16 || a.KeyLength < 16 || a.KeyLength > 64 {
+		return fmt.Errorf("argon2 needs a salt of at least 16 bytes and a key of 16 to 64 bytes")
+	}
+	if p.BcryptCost < 10 || p.BcryptCost > maxBcryptCost {
+		return fmt.Errorf("bcrypt cost must be between 10 and %d", maxBcryptCost)
+	}
+	return nil
+}
+
+// IPasswordHasher hashes and verifies passwords as PHC strings.
+type IPasswordHasher interface {
+	Hash(password string, algorithm models.PasswordAlgorithm) (string, error)
+	Verify(password, encoded string) (valid bool, needsRehash bool, err error)
+	NeedsRehash(encoded string) (bool, error)
+}
+
+// PasswordHasher emits Argon2id hashes in the PHC string format
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash) and bcrypt hashes in their
+// standard $2a$ modular crypt format.
+type PasswordHasher struct {
+	policy PasswordHashPolicy
+}
+
+func NewPasswordHasher(policy PasswordHashPolicy) *PasswordHasher {
+	return &PasswordHasher{policy: policy}
+}
+
+// Hash hashes a password with the given algorithm, or the policy algorithm when empty.
+func (h *PasswordHasher) Hash(password string, algorithm models.PasswordAlgorithm) (string, error) {
+	if len(password) > maxPasswordLength {
+		return "", fmt.Errorf("%w: passwords may be at most %d bytes", ErrInvalidPassword, maxPasswordLength)
+	}
+	if algorithm == "" {
+		algorithm = h.policy.Algorithm
+	}
+
+	switch algorithm {
+	case models.PasswordAlgorithmArgon2id:
+		salt := make([]byte, h.policy.Argon2.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+		return encodeArgon2id(h.policy.Argon2, salt, password), nil
+	case models.PasswordAlgorithmBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.policy.BcryptCost)
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+				return "", fmt.Errorf("%w: bcrypt passwords may be at most 72 bytes", ErrInvalidPassword)
+			}
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hash), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedPasswordAlgorithm, algorithm)
+	}
+}
+
+// Verify checks a password against a hash in constant time and reports whether
+// the hash should be replaced with one made under the current policy. Hashes with
+// more than twice the policy's Argon2 memory (never above maxArgon2MemoryKiB),
+// iterations or lanes, or a bcrypt cost above the policy cost plus one are
+// rejected with ErrInvalidPasswordHash.
+func (h *PasswordHasher) Verify(password, encoded string) (bool, bool, error) {
+	needsRehash, err := h.NeedsRehash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	if len(password) > maxPasswordLength {
+		return false, needsRehash, nil
+	}
+
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		policy := h.policy.Argon2
+		memoryLimit := min(2*policy.MemoryKiB, maxArgon2MemoryKiB)
+		if params.MemoryKiB > memoryLimit || params.Iterations > 2*policy.Iterations ||
+			uint32(params.Parallelism) > 2*uint32(policy.Parallelism) {
+			return false, false, fmt.Errorf("%w: argon2 parameters m=%d,t=%d,p=%d exceed the verification limit m=%d,t=%d,p=%d",
+				ErrInvalidPasswordHash, params.MemoryKiB, params.Iterations, params.Parallelism,
+				memoryLimit, 2*policy.Iterations, 2*uint32(policy.Parallelism))
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(computed, key) == 1, needsRehash, nil
+	}
+
+	// Each bcrypt cost step doubles the work, so allow one step above the policy.
+	if cost, _ := bcrypt.Cost([]byte(encoded)); cost > h.policy.BcryptCost+1 {
+		return false, false, fmt.Errorf("%w: bcrypt cost %d exceeds the verification limit %d",
+			ErrInvalidPasswordHash, cost, h.policy.BcryptCost+1)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, needsRehash, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword), errors.Is(err, bcrypt.ErrPasswordTooLong):
+		return false, needsRehash, nil
+	default:
+		return false, false, fmt.Errorf("%w: %v", ErrInvalidPasswordHash, err)
+	}
+}
+
+// NeedsRehash reports whether a hash was made with another algorithm or other
+// parameters than the current policy.
+func (h *PasswordHasher) NeedsRehash(encoded string) (bool, error) {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, _, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		current := h.policy.Argon2
+		params.SaltLength = uint32(len(salt))
+		return h.policy.Algorithm != models.PasswordAlgorithmArgon2id || params != current, nil
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, fmt.Errorf("%w: unrecognized hash format", ErrInvalidPasswordHash)
+	}
+	if cost > maxBcryptCost {
+		return false, fmt.Errorf("%w: bcrypt cost %d exceeds %d", ErrInvalidPasswordHash, cost, maxBcryptCost)
+	}
+	return h.policy.Algorithm != models.PasswordAlgorithmBcrypt || cost != h.policy.BcryptCost, nil
+}
+
+func encodeArgon2id(params Argon2Params, salt []byte, password string) string {
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, params.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.MemoryKiB, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id parses an Argon2id PHC string and enforces the verification bounds.
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: malformed argon2id hash", ErrInvalidPasswordHash)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: unsupported argon2 version", ErrInvalidPasswordHash)
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: malformed argon2 parameters", ErrInvalidPasswordHash)
+	}
+	if params.Parallelism < 1 || params.Parallelism > maxArgon2Threads ||
+		params.Iterations < 1 || params.Iterations > maxArgon2Iterations ||
+		params.MemoryKiB < 8*uint32(params.Parallelism) || params.MemoryKiB > maxArgon2MemoryKiB {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: argon2 parameters out of range", ErrInvalidPasswordHash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) < 8 || len(salt) > 64 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: invalid argon2 salt", ErrInvalidPasswordHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) < 16 || len(key) > 64 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: invalid argon2 hash", ErrInvalidPasswordHash)
+	}
+
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,109 @@
+package processors_test
+
+import (
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"strings"
+	"testing"
+)
+
+func testPasswordPolicy() processors.PasswordHashPolicy {
+	policy := processors.DefaultPasswordHashPolicy()
+	policy.BcryptCost = 10
+	return policy
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher := processors.NewPasswordHasher(testPasswordPolicy())
+
+	hash, err := hasher.Hash("correct horse battery staple", "")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Unexpected PHC string: %s", hash)
+	}
+
+	valid, needsRehash, err := hasher.Verify("correct horse battery staple", hash)
+	if err != nil || !valid || needsRehash {
+		t.Errorf("Expected a valid, current hash, got valid=%v needsRehash=%v err=%v", valid, needsRehash, err)
+	}
+	if valid, _, _ := hasher.Verify("correct horse battery stapler", hash); valid {
+		t.Error("Wrong password accepted")
+	}
+}
+
+func TestPasswordHasher_NeedsRehashAfterUpgrade(t *testing.T) {
+	old := processors.NewPasswordHasher(testPasswordPolicy())
+	argonHash, err := old.Hash("hunter22", models.PasswordAlgorithmArgon2id)
+	if err != nil {
+		t.Fatalf("Hash(argon2id) failed: %v", err)
+	}
+	bcryptHash, err := old.Hash("hunter22", models.PasswordAlgorithmBcrypt)
+	if err != nil {
+		t.Fatalf("Hash(bcrypt) failed: %v", err)
+	}
+	if !strings.HasPrefix(bcryptHash, "$2a$10$") {
+		t.Errorf("Unexpected bcrypt hash: %s", bcryptHash)
+	}
+
+	upgraded := testPasswordPolicy()
+	upgraded.Argon2.Iterations = 3
+	hasher := processors.NewPasswordHasher(upgraded)
+
+	for name, hash := range map[string]string{"argon2id": argonHash, "bcrypt": bcryptHash} {
+		valid, needsRehash, err := hasher.Verify("hunter22", hash)
+		if err != nil || !valid {
+			t.Errorf("%s: old hash no longer verifies: %v", name, err)
+		}
+		if !needsRehash {
+			t.Errorf("%s: expected needsRehash after the policy upgrade", name)
+		}
+	}
+}
+
+func TestPasswordHasher_VerifiesAfterMemoryDowngrade(t *testing.T) {
+	previous := testPasswordPolicy()
+	previous.Argon2.MemoryKiB = 32 * 1024
+	hash, err := processors.NewPasswordHasher(previous).Hash("hunter22", models.PasswordAlgorithmArgon2id)
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+
+	hasher := processors.NewPasswordHasher(testPasswordPolicy())
+	valid, needsRehash, err := hasher.Verify("hunter22", hash)
+	if err != nil || !valid {
+		t.Fatalf("Hash made with more memory than the policy no longer verifies: valid=%v err=%v", valid, err)
+	}
+	if !needsRehash {
+		t.Error("Expected needsRehash for a hash made with more memory than the policy")
+	}
+}
+
+func TestPasswordHasher_RejectsUntrustedHashes(t *testing.T) {
+	hasher := processors.NewPasswordHasher(testPasswordPolicy())
+
+	hashes := []string{
+		"$argon2id$v=19$m=4194304,t=1,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=262144,t=2,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=19456,t=16,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=19456,t=2,p=16$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$2a$16$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234",
+		"$2a$31$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234",
+		"plaintext",
+	}
+	for _, hash := range hashes {
+		if _, _, err := hasher.Verify("password", hash); !errors.Is(err, processors.ErrInvalidPasswordHash) {
+			t.Errorf("Expected ErrInvalidPasswordHash for %q, got: %v", hash, err)
+		}
+	}
+
+	if _, err := hasher.Hash(strings.Repeat("a", 73), models.PasswordAlgorithmBcrypt); !errors.Is(err, processors.ErrInvalidPassword) {
+		t.Errorf("Expected ErrInvalidPassword for a 73 byte bcrypt password, got: %v", err)
+	}
+	if _, err := hasher.Hash("password", "scrypt"); !errors.Is(err, processors.ErrUnsupportedPasswordAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedPasswordAlgorithm, got: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package processors
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupportedPayloadEncoding is returned for an unknown encoding name.
+	ErrUnsupportedPayloadEncoding = errors.New("unsupported payload encoding")
+	// ErrInvalidPayload is returned for input that does not match its encoding and
+	// for binary output that cannot be represented as UTF-8 text.
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// DecodePayload converts a payload received as a JSON string into its bytes.
+// An empty encoding defaults to UTF-8.
+func DecodePayload(value string, encoding models.PayloadEncoding) ([]byte, error) {
+	var data []byte
+	var err error
+	switch encoding {
+	case models.PayloadEncodingUTF8, "":
+		return []byte(value), nil
+	case models.PayloadEncodingBase64:
+		data, err = base64.StdEncoding.DecodeString(value)
+	case models.PayloadEncodingBase64URL:
+		// Padding is optional in base64url, so accept both forms.
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	case models.PayloadEncodingHex:
+		data, err = hex.DecodeString(value)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPayloadEncoding, encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: not valid %s: %v", ErrInvalidPayload, encoding, err)
+	}
+	return data, nil
+}
+
+// EncodePayload converts payload bytes into a JSON string in the given encoding.
+// UTF-8 output is refused for bytes that are not valid UTF-8 instead of being
+// silently replaced, so binary data never comes back corrupted.
+func EncodePayload(data []byte, encoding models.PayloadEncoding) (string, error) {
+	switch encoding {
+	case models.PayloadEncodingUTF8, "":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w: payload is not valid UTF-8, request base64, base64url or hex", ErrInvalidPayload)
+		}
+		return string(data), nil
+	case models.PayloadEncodingBase64:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case models.PayloadEncodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data), nil
+	case models.PayloadEncodingHex:
+		return hex.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedPayloadEncoding, encoding)
+	}
+}
+
+// DecodeBinaryPayload decodes a binary value such as a ciphertext, signature or MAC.
+// Binary values are never UTF-8 text, so an empty or utf8 encoding means base64.
+func DecodeBinaryPayload(value string, encoding models.PayloadEncoding) ([]byte, error) {
+	return DecodePayload(value, binaryEncoding(encoding))
+}
+
+// EncodeBinaryPayload encodes a binary value such as a ciphertext, signature or MAC
+// like DecodeBinaryPayload expects it.
+func EncodeBinaryPayload(data []byte, encoding models.PayloadEncoding) (string, error) {
+	return EncodePayload(data, binaryEncoding(encoding))
+}
+
+func binaryEncoding(encoding models.PayloadEncoding) models.PayloadEncoding {
+	if encoding == "" || encoding == models.PayloadEncodingUTF8 {
+		return models.PayloadEncodingBase64
+	}
+	return encoding
+}
@@ -0,0 +1,94 @@
+package processors_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+func TestPayloadEncoding_RoundTrip(t *testing.T) {
+	binary := []byte{0xff, 0x00, 0xfe, 0x80, 'a'}
+	expected := map[models.PayloadEncoding]string{
+		models.PayloadEncodingBase64:    "/wD+gGE=",
+		models.PayloadEncodingBase64URL: "_wD-gGE",
+		models.PayloadEncodingHex:       "ff00fe8061",
+	}
+
+	for encoding, want := range expected {
+		encoded, err := processors.EncodePayload(binary, encoding)
+		if err != nil || encoded != want {
+			t.Errorf("EncodePayload(%s) = %q, %v; expected %q", encoding, encoded, err, want)
+		}
+		decoded, err := processors.DecodePayload(encoded, encoding)
+		if err != nil || !bytes.Equal(decoded, binary) {
+			t.Errorf("DecodePayload(%s) = %x, %v; expected %x", encoding, decoded, err, binary)
+		}
+	}
+
+	if decoded, err := processors.DecodePayload("_wD-gGE=", models.PayloadEncodingBase64URL); err != nil || !bytes.Equal(decoded, binary) {
+		t.Errorf("Padded base64url was not accepted: %x, %v", decoded, err)
+	}
+	if decoded, err := processors.DecodePayload("FF00FE8061", models.PayloadEncodingHex); err != nil || !bytes.Equal(decoded, binary) {
+		t.Errorf("Uppercase hex was not accepted: %x, %v", decoded, err)
+	}
+}
+
+func TestPayloadEncoding_UTF8(t *testing.T) {
+	text, err := processors.EncodePayload([]byte("Grüße"), "")
+	if err != nil || text != "Grüße" {
+		t.Errorf("UTF-8 text did not roundtrip: %q, %v", text, err)
+	}
+
+	if _, err := processors.EncodePayload([]byte{0xff, 0xfe}, models.PayloadEncodingUTF8); !errors.Is(err, processors.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for binary output as UTF-8, got: %v", err)
+	}
+}
+
+func TestPayloadEncoding_Invalid(t *testing.T) {
+	if _, err := processors.DecodePayload("not base64!", models.PayloadEncodingBase64); !errors.Is(err, processors.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for malformed base64, got: %v", err)
+	}
+	if _, err := processors.DecodePayload("abc", models.PayloadEncodingHex); !errors.Is(err, processors.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for odd-length hex, got: %v", err)
+	}
+	if _, err := processors.DecodePayload("data", "ascii85"); !errors.Is(err, processors.ErrUnsupportedPayloadEncoding) {
+		t.Errorf("Expected ErrUnsupportedPayloadEncoding, got: %v", err)
+	}
+}
+
+func TestPayloadEncoding_BinaryCiphertexts(t *testing.T) {
+	aesKey, err := aesService.GenerateSecretKey()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	cipherText, err := aesService.Encrypt(aesKey, models.AesModeGCM, []byte("encoded ciphertext"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(cipherText)
+
+	for _, encoding := range []models.PayloadEncoding{models.PayloadEncodingHex, models.PayloadEncodingBase64URL, ""} {
+		encoded, err := processors.EncodeBinaryPayload(sealed, encoding)
+		if err != nil {
+			t.Fatalf("EncodeBinaryPayload(%q) failed: %v", encoding, err)
+		}
+		decoded, err := processors.DecodeBinaryPayload(encoded, encoding)
+		if err != nil {
+			t.Fatalf("DecodeBinaryPayload(%q) failed: %v", encoding, err)
+		}
+		plaintext, err := aesService.Decrypt(aesKey, models.AesModeGCM, base64.StdEncoding.EncodeToString(decoded), nil)
+		if err != nil || string(plaintext) != "encoded ciphertext" {
+			t.Errorf("%q: ciphertext did not roundtrip: %q, %v", encoding, plaintext, err)
+		}
+	}
+
+	if encoded, _ := processors.EncodeBinaryPayload(sealed, models.PayloadEncodingUTF8); encoded != cipherText {
+		t.Errorf("Binary values requested as utf8 should fall back to base64, got %q", encoded)
+	}
+	if _, err := processors.DecodeBinaryPayload(cipherText, models.PayloadEncodingHex); !errors.Is(err, processors.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload for a base64 ciphertext declared as hex, got: %v", err)
+	}
+}
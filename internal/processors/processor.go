@@ -1,6 +1,8 @@
 package processors
 
 import (
+	"fmt"
+	"laba6/internal/models"
 	"laba6/internal/repositories"
 )
 
@@ -8,12 +10,52 @@ type Processors struct {
 	EmployeeProcessor *EmployeeProcessor
 	Rsa               IRsaService
 	Aes               IAesService
+	AesStream         IAesStreamService
+	Hmac              IHmacService
+	Passwords         IPasswordHasher
+	Kdf               IKdfService
+	Ec                IEcService
+	X25519            IX25519Service
+	Import            IKeyImportService
+	Export            IKeyExportService
+	Certificates      ICertificateService
+	Ca                ICaService
 }
 
-func NewProcessors(repos *repositories.Repositories, rsaBits int, aesKeySize int) *Processors {
+func NewProcessors(repos *repositories.Repositories, rsaPolicy RsaKeyPolicy, passwordPolicy PasswordHashPolicy, aesKeySize int,
+	requireEmployeeVersion bool) *Processors {
 	return &Processors{
-		EmployeeProcessor: NewEmployeeProcessor(repos.EmployeeRepository),
-		Rsa:               NewRsaService(rsaBits),
+		EmployeeProcessor: NewEmployeeProcessor(repos.EmployeeRepository, requireEmployeeVersion),
+		Rsa:               NewRsaService(rsaPolicy),
 		Aes:               NewAesService(aesKeySize),
+		AesStream:         NewAesStreamService(DefaultStreamSegmentSize),
+		Hmac:              NewHmacService(),
+		Passwords:         NewPasswordHasher(passwordPolicy),
+		Kdf:               NewKdfService(),
+		Ec:                NewEcService(),
+		X25519:            NewX25519Service(),
+		Import:            NewKeyImportService(rsaPolicy),
+		Export:            NewKeyExportService(),
+		Certificates:      NewCertificateService(),
+		Ca:                NewCaService(),
+	}
+}
+
+// GenerateKeyPair generates a key pair of any supported algorithm. keySize only
+// applies to RSA, where zero selects the policy default.
+func (p *Processors) GenerateKeyPair(algorithm models.KeyAlgorithm, keySize int) (models.KeyPair, error) {
+	switch algorithm {
+	case models.KeyAlgorithmRSA:
+		keys, err := p.Rsa.GenerateCryptoKeysWithParams(keySize, 0)
+		if err != nil {
+			return models.KeyPair{}, err
+		}
+		return models.KeyPair{Algorithm: models.KeyAlgorithmRSA, KeySize: keys.KeySize, PublicKey: keys.PublicKey, PrivateKey: keys.PrivateKey}, nil
+	case models.KeyAlgorithmECDSAP256, models.KeyAlgorithmECDSAP384, models.KeyAlgorithmEd25519:
+		return p.Ec.GenerateKeyPair(algorithm)
+	case models.KeyAlgorithmX25519:
+		return p.X25519.GenerateKeyPair()
+	default:
+		return models.KeyPair{}, fmt.Errorf("%w: %q", ErrUnsupportedKeyAlgorithm, algorithm)
 	}
 }
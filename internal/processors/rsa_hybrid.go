@@ -0,0 +1,178 @@
+package processors
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"laba6/internal/models"
+)
+
+const (
+	hybridEnvelopeVersion = 1
+	hybridContentKeySize  = 32
+	hybridNonceSize       = 12
+)
+
+// hybridBinaryMagic prefixes the compact binary envelope:
+// magic (2) || version (1) || key length (2, big endian) || encrypted key || nonce (12) || ciphertext+tag.
+var hybridBinaryMagic = []byte("RH")
+
+// HybridEncrypt encrypts a payload of any size: a fresh AES-256-GCM content key
+// encrypts the payload and is itself wrapped with RSA-OAEP (SHA-256).
+func (s *RsaService) HybridEncrypt(publicKeyPEM string, plaintext []byte) (models.HybridEnvelope, error) {
+	pub, err := parseRsaPublicKey(publicKeyPEM)
+	if err != nil {
+		return models.HybridEnvelope{}, err
+	}
+	if err := s.policy.checkPublicKey(pub); err != nil {
+		return models.HybridEnvelope{}, err
+	}
+
+	// 1. Ephemeral content key and nonce
+	contentKey := make([]byte, hybridContentKeySize)
+	if _, err := io.ReadFull(rand.Reader, contentKey); err != nil {
+		return models.HybridEnvelope{}, fmt.Errorf("failed to generate content key: %w", err)
+	}
+	nonce := make([]byte, hybridNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return models.HybridEnvelope{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// 2. Encrypt the payload, binding the algorithm identifier as associated data
+	gcm, err := newHybridGCM(contentKey)
+	if err != nil {
+		return models.HybridEnvelope{}, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, []byte(models.HybridAlgorithm))
+
+	// 3. Wrap the content key with RSA-OAEP
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, contentKey, nil)
+	if err != nil {
+		return models.HybridEnvelope{}, fmt.Errorf("failed to wrap content key: %w", err)
+	}
+
+	return models.HybridEnvelope{
+		Version:      hybridEnvelopeVersion,
+		Algorithm:    models.HybridAlgorithm,
+		EncryptedKey: base64.StdEncoding.EncodeToString(encryptedKey),
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		CipherText:   base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// HybridDecrypt reverses HybridEncrypt.
+func (s *RsaService) HybridDecrypt(privateKeyPEM string, envelope models.HybridEnvelope) ([]byte, error) {
+	if envelope.Version != hybridEnvelopeVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHybridEnvelope, envelope.Version)
+	}
+	if envelope.Algorithm != models.HybridAlgorithm {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidHybridEnvelope, envelope.Algorithm)
+	}
+
+	priv, err := parseRsaPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(envelope.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode encrypted key: %v", ErrInvalidHybridEnvelope, err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil || len(nonce) != hybridNonceSize {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidHybridEnvelope)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode base64 ciphertext: %v", ErrInvalidHybridEnvelope, err)
+	}
+
+	// A wrong private key or a tampered encrypted key fails here.
+	contentKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, encryptedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to unwrap content key: %v", ErrInvalidHybridEnvelope, err)
+	}
+	if len(contentKey) != hybridContentKeySize {
+		return nil, fmt.Errorf("%w: content key must be %d bytes", ErrInvalidHybridEnvelope, hybridContentKeySize)
+	}
+
+	gcm, err := newHybridGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(models.HybridAlgorithm))
+	if err != nil {
+		return nil, ErrAesAuthenticationFailed
+	}
+
+	return plaintext, nil
+}
+
+// MarshalHybridEnvelope serializes an envelope into the compact binary form.
+func MarshalHybridEnvelope(envelope models.HybridEnvelope) ([]byte, error) {
+	encryptedKey, err := base64.StdEncoding.DecodeString(envelope.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted key: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil || len(nonce) != hybridNonceSize {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 ciphertext: %w", err)
+	}
+
+	out := make([]byte, 0, len(hybridBinaryMagic)+3+len(encryptedKey)+len(nonce)+len(ciphertext))
+	out = append(out, hybridBinaryMagic...)
+	out = append(out, byte(envelope.Version))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(encryptedKey)))
+	out = append(out, encryptedKey...)
+	out = append(out, nonce...)
+	out = append(out, ciphertext...)
+	return out, nil
+}
+
+// UnmarshalHybridEnvelope parses the compact binary form produced by MarshalHybridEnvelope.
+func UnmarshalHybridEnvelope(data []byte) (models.HybridEnvelope, error) {
+	header := len(hybridBinaryMagic) + 3
+	if len(data) < header || string(data[:len(hybridBinaryMagic)]) != string(hybridBinaryMagic) {
+		return models.HybridEnvelope{}, fmt.Errorf("%w: not a binary hybrid envelope", ErrInvalidHybridEnvelope)
+	}
+
+	version := int(data[len(hybridBinaryMagic)])
+	keyLength := int(binary.BigEndian.Uint16(data[len(hybridBinaryMagic)+1 : header]))
+	if len(data) < header+keyLength+hybridNonceSize {
+		return models.HybridEnvelope{}, fmt.Errorf("%w: binary envelope is truncated", ErrInvalidHybridEnvelope)
+	}
+
+	encryptedKey := data[header : header+keyLength]
+	nonce := data[header+keyLength : header+keyLength+hybridNonceSize]
+	ciphertext := data[header+keyLength+hybridNonceSize:]
+
+	return models.HybridEnvelope{
+		Version:      version,
+		Algorithm:    models.HybridAlgorithm,
+		EncryptedKey: base64.StdEncoding.EncodeToString(encryptedKey),
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		CipherText:   base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+func newHybridGCM(contentKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
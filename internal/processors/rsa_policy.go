@@ -0,0 +1,71 @@
+package processors
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// RsaPublicExponent is the only public exponent crypto/rsa generates (F4).
+const RsaPublicExponent = 65537
+
+// ErrRsaKeyPolicyViolation is returned for an RSA key size or exponent the server policy does not allow.
+var ErrRsaKeyPolicyViolation = errors.New("RSA key policy violation")
+
+// RsaKeyPolicy restricts the RSA keys the server generates and accepts.
+// AllowedBits lists the sizes a generation request may ask for; MinBits is
+// enforced when encrypting or verifying with any key, including imported ones.
+type RsaKeyPolicy struct {
+	DefaultBits int
+	AllowedBits []int
+	MinBits     int
+}
+
+// DefaultRsaKeyPolicy returns the policy used when none is configured.
+func DefaultRsaKeyPolicy() RsaKeyPolicy {
+	return RsaKeyPolicy{DefaultBits: 2048, AllowedBits: []int{2048, 3072, 4096}, MinBits: 2048}
+}
+
+// Validate checks that the policy is internally consistent.
+func (p RsaKeyPolicy) Validate() error {
+	if len(p.AllowedBits) == 0 {
+		return fmt.Errorf("at least one RSA key size must be allowed")
+	}
+	for _, bits := range p.AllowedBits {
+		if bits < p.MinBits {
+			return fmt.Errorf("allowed RSA key size %d is below the %d-bit minimum", bits, p.MinBits)
+		}
+	}
+	if !slices.Contains(p.AllowedBits, p.DefaultBits) {
+		return fmt.Errorf("default RSA key size %d is not in the allowed sizes %v", p.DefaultBits, p.AllowedBits)
+	}
+	return nil
+}
+
+// Allows reports whether keys of the given size may be generated.
+func (p RsaKeyPolicy) Allows(bits int) bool {
+	return slices.Contains(p.AllowedBits, bits)
+}
+
+// generationParams resolves the requested size and exponent, where zero selects the default.
+func (p RsaKeyPolicy) generationParams(bits, exponent int) (int, error) {
+	if bits == 0 {
+		bits = p.DefaultBits
+	}
+	if !p.Allows(bits) {
+		return 0, fmt.Errorf("%w: key size %d is not one of %v", ErrRsaKeyPolicyViolation, bits, p.AllowedBits)
+	}
+	if exponent != 0 && exponent != RsaPublicExponent {
+		return 0, fmt.Errorf("%w: public exponent %d is not supported, only %d", ErrRsaKeyPolicyViolation, exponent, RsaPublicExponent)
+	}
+	return bits, nil
+}
+
+// checkPublicKey rejects keys smaller than the policy minimum.
+func (p RsaKeyPolicy) checkPublicKey(pub *rsa.PublicKey) error {
+	if size := pub.N.BitLen(); size < p.MinBits {
+		return fmt.Errorf("%w: %d-bit key is below the %d-bit minimum", ErrRsaKeyPolicyViolation, size, p.MinBits)
+	}
+	return nil
+}
@@ -1,34 +1,72 @@
 package processors
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"laba6/internal/models"
 )
 
+var (
+	// ErrUnsupportedSignatureScheme is returned for an unknown signature scheme.
+	ErrUnsupportedSignatureScheme = errors.New("unsupported signature scheme")
+	// ErrUnsupportedHash is returned for an unknown hash algorithm.
+	ErrUnsupportedHash = errors.New("unsupported hash algorithm")
+	// ErrRsaDecryptionFailed is returned for a ciphertext that is not valid base64 or
+	// does not decrypt with the given private key.
+	ErrRsaDecryptionFailed = errors.New("RSA decryption failed")
+	// ErrInvalidHybridEnvelope is returned for a hybrid envelope that is malformed or
+	// whose content key cannot be unwrapped with the given private key.
+	ErrInvalidHybridEnvelope = errors.New("invalid hybrid envelope")
+)
+
 type IRsaService interface {
 	GenerateCryptoKeys() (models.RsaKeys, error)
-	Encrypt(publicKey, plainText string) (string, error)
-	Decrypt(privateKey, cipherTextBase64 string) (string, error)
+	GenerateCryptoKeysWithParams(bits, exponent int) (models.RsaKeys, error)
+	KeyPolicy() RsaKeyPolicy
+	Encrypt(publicKey string, plaintext []byte) (string, error)
+	Decrypt(privateKey, cipherTextBase64 string) ([]byte, error)
+	Sign(privateKey string, message []byte, scheme models.SignatureScheme, hash models.HashAlgorithm) (string, error)
+	Verify(publicKey string, message []byte, signatureBase64 string, scheme models.SignatureScheme, hash models.HashAlgorithm) (bool, error)
+	HybridEncrypt(publicKey string, plaintext []byte) (models.HybridEnvelope, error)
+	HybridDecrypt(privateKey string, envelope models.HybridEnvelope) ([]byte, error)
 }
 
 type RsaService struct {
-	bits int // Key size in bits (e.g., 2048, 4096)
+	policy RsaKeyPolicy
 }
 
-func NewRsaService(bits int) *RsaService {
-	return &RsaService{bits: bits}
+func NewRsaService(policy RsaKeyPolicy) *RsaService {
+	return &RsaService{policy: policy}
 }
 
-// GenerateCryptoKeys generates a new RSA key pair.
+// KeyPolicy returns the key size policy the service enforces.
+func (s *RsaService) KeyPolicy() RsaKeyPolicy {
+	return s.policy
+}
+
+// GenerateCryptoKeys generates a new RSA key pair of the policy's default size.
 func (s *RsaService) GenerateCryptoKeys() (models.RsaKeys, error) {
+	return s.GenerateCryptoKeysWithParams(0, 0)
+}
+
+// GenerateCryptoKeysWithParams generates a new RSA key pair with the requested
+// size and public exponent; zero values select the defaults.
+func (s *RsaService) GenerateCryptoKeysWithParams(bits, exponent int) (models.RsaKeys, error) {
+	bits, err := s.policy.generationParams(bits, exponent)
+	if err != nil {
+		return models.RsaKeys{}, err
+	}
+
 	// 1. Generate Private Key
-	privateKey, err := rsa.GenerateKey(rand.Reader, s.bits)
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return models.RsaKeys{}, fmt.Errorf("failed to generate private key: %w", err)
 	}
@@ -55,32 +93,27 @@ func (s *RsaService) GenerateCryptoKeys() (models.RsaKeys, error) {
 	)
 
 	return models.RsaKeys{
+		KeySize:    bits,
 		PrivateKey: string(privatePEM),
 		PublicKey:  string(publicPEM),
 	}, nil
 }
 
-func (s *RsaService) Encrypt(publicKeyPEM, plainText string) (string, error) {
+func (s *RsaService) Encrypt(publicKeyPEM string, plaintext []byte) (string, error) {
 	// 1. Decode Public Key from PEM
-	block, _ := pem.Decode([]byte(publicKeyPEM))
-	if block == nil {
-		return "", fmt.Errorf("failed to decode public key PEM block")
-	}
-
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	rsaPubKey, err := parseRsaPublicKey(publicKeyPEM)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse public key: %w", err)
+		return "", err
 	}
-	rsaPubKey, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return "", fmt.Errorf("key is not an RSA public key")
+	if err := s.policy.checkPublicKey(rsaPubKey); err != nil {
+		return "", err
 	}
 
 	ciphertext, err := rsa.EncryptOAEP(
 		sha256.New(),
 		rand.Reader,
 		rsaPubKey,
-		[]byte(plainText),
+		plaintext,
 		nil,
 	)
 	if err != nil {
@@ -89,22 +122,17 @@ func (s *RsaService) Encrypt(publicKeyPEM, plainText string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-func (s *RsaService) Decrypt(privateKeyPEM, cipherTextBase64 string) (string, error) {
+func (s *RsaService) Decrypt(privateKeyPEM, cipherTextBase64 string) ([]byte, error) {
 	// 1. Decode Private Key from PEM
-	block, _ := pem.Decode([]byte(privateKeyPEM))
-	if block == nil {
-		return "", fmt.Errorf("failed to decode private key PEM block")
-	}
-
-	priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	priv, err := parseRsaPrivateKey(privateKeyPEM)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse private key: %w", err)
+		return nil, err
 	}
 
 	// 2. Decode the Base64 ciphertext
 	ciphertext, err := base64.StdEncoding.DecodeString(cipherTextBase64)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 ciphertext: %w", err)
+		return nil, fmt.Errorf("%w: failed to decode base64 ciphertext: %v", ErrRsaDecryptionFailed, err)
 	}
 
 	plaintextBytes, err := rsa.DecryptOAEP(
@@ -115,8 +143,137 @@ func (s *RsaService) Decrypt(privateKeyPEM, cipherTextBase64 string) (string, er
 		nil,
 	)
 	if err != nil {
-		return "", fmt.Errorf("decryption failed: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrRsaDecryptionFailed, err)
+	}
+
+	return plaintextBytes, nil
+}
+
+// Sign signs the message and returns a base64 encoded signature.
+// An empty scheme defaults to PSS and an empty hash to SHA-256.
+func (s *RsaService) Sign(privateKeyPEM string, message []byte, scheme models.SignatureScheme, hash models.HashAlgorithm) (string, error) {
+	priv, err := parseRsaPrivateKey(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	cryptoHash, digest, err := digestMessage(hash, message)
+	if err != nil {
+		return "", err
 	}
 
-	return string(plaintextBytes), nil
+	var signature []byte
+	switch scheme {
+	case models.SignatureSchemePSS, "":
+		signature, err = rsa.SignPSS(rand.Reader, priv, cryptoHash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case models.SignatureSchemePKCS1v15:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, priv, cryptoHash, digest)
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedSignatureScheme, scheme)
+	}
+	if err != nil {
+		return "", fmt.Errorf("signing failed: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a base64 encoded signature over the message. It returns false
+// for a well-formed but invalid signature and an error for malformed input.
+func (s *RsaService) Verify(publicKeyPEM string, message []byte, signatureBase64 string, scheme models.SignatureScheme, hash models.HashAlgorithm) (bool, error) {
+	pub, err := parseRsaPublicKey(publicKeyPEM)
+	if err != nil {
+		return false, err
+	}
+	if err := s.policy.checkPublicKey(pub); err != nil {
+		return false, err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode base64 signature: %w", err)
+	}
+
+	cryptoHash, digest, err := digestMessage(hash, message)
+	if err != nil {
+		return false, err
+	}
+
+	switch scheme {
+	case models.SignatureSchemePSS, "":
+		err = rsa.VerifyPSS(pub, cryptoHash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	case models.SignatureSchemePKCS1v15:
+		err = rsa.VerifyPKCS1v15(pub, cryptoHash, digest, signature)
+	default:
+		return false, fmt.Errorf("%w: %q", ErrUnsupportedSignatureScheme, scheme)
+	}
+
+	return err == nil, nil
+}
+
+// digestMessage hashes the message with the named algorithm (SHA-256 by default).
+func digestMessage(hash models.HashAlgorithm, message []byte) (crypto.Hash, []byte, error) {
+	switch hash {
+	case models.HashSHA256, "":
+		digest := sha256.Sum256(message)
+		return crypto.SHA256, digest[:], nil
+	case models.HashSHA384:
+		digest := sha512.Sum384(message)
+		return crypto.SHA384, digest[:], nil
+	case models.HashSHA512:
+		digest := sha512.Sum512(message)
+		return crypto.SHA512, digest[:], nil
+	default:
+		return 0, nil, fmt.Errorf("%w: %q", ErrUnsupportedHash, hash)
+	}
+}
+
+func parseRsaPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM block")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return pub, nil
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	rsaPubKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not an RSA public key")
+	}
+	return rsaPubKey, nil
+}
+
+func parseRsaPrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM block")
+	}
+
+	if block.Type == "PRIVATE KEY" {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key is not an RSA private key")
+		}
+		return priv, nil
+	}
+
+	priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return priv, nil
 }
@@ -1,11 +1,14 @@
 package processors_test
 
 import (
+	"errors"
+	"laba6/internal/models"
 	"laba6/internal/processors"
+	"strings"
 	"testing"
 )
 
-var rsaService = processors.NewRsaService(2048)
+var rsaService = processors.NewRsaService(processors.DefaultRsaKeyPolicy())
 
 func TestRsaService_GenerateCryptoKeys(t *testing.T) {
 	keys, err := rsaService.GenerateCryptoKeys()
@@ -34,7 +37,7 @@ func TestRsaService_EncryptDecrypt(t *testing.T) {
 		t.Fatalf("Setup failed: Could not generate keys: %v", err)
 	}
 
-	ciphertext, err := rsaService.Encrypt(keys.PublicKey, originalMessage)
+	ciphertext, err := rsaService.Encrypt(keys.PublicKey, []byte(originalMessage))
 	if err != nil {
 		t.Fatalf("Encrypt failed: %v", err)
 	}
@@ -48,7 +51,7 @@ func TestRsaService_EncryptDecrypt(t *testing.T) {
 		t.Fatalf("Decrypt failed: %v", err)
 	}
 
-	if decryptedMessage != originalMessage {
+	if string(decryptedMessage) != originalMessage {
 		t.Errorf("Decryption mismatch:\nExpected: %s\nActual: %s", originalMessage, decryptedMessage)
 	}
 }
@@ -66,14 +69,214 @@ func TestRsaService_Decrypt_InvalidKey(t *testing.T) {
 		t.Fatalf("Setup failed: Could not generate second keyset: %v", err)
 	}
 
-	ciphertext, err := rsaService.Encrypt(keys1.PublicKey, originalMessage)
+	ciphertext, err := rsaService.Encrypt(keys1.PublicKey, []byte(originalMessage))
 	if err != nil {
 		t.Fatalf("Encrypt failed: %v", err)
 	}
 
 	_, err = rsaService.Decrypt(keys2.PrivateKey, ciphertext)
 
-	if err == nil {
-		t.Error("Decrypt should have failed when using a wrong private key, but it succeeded.")
+	if !errors.Is(err, processors.ErrRsaDecryptionFailed) {
+		t.Errorf("Expected ErrRsaDecryptionFailed when using a wrong private key, got: %v", err)
+	}
+
+	for name, malformed := range map[string]string{"truncated": ciphertext[:len(ciphertext)/2], "not base64": "not base64!"} {
+		if _, err := rsaService.Decrypt(keys1.PrivateKey, malformed); !errors.Is(err, processors.ErrRsaDecryptionFailed) {
+			t.Errorf("%s: expected ErrRsaDecryptionFailed, got: %v", name, err)
+		}
+	}
+}
+
+func TestRsaService_SignVerify(t *testing.T) {
+	const message = "Document to be signed"
+
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	cases := []struct {
+		scheme models.SignatureScheme
+		hash   models.HashAlgorithm
+	}{
+		{models.SignatureSchemePSS, models.HashSHA256},
+		{models.SignatureSchemePSS, models.HashSHA384},
+		{models.SignatureSchemePSS, models.HashSHA512},
+		{models.SignatureSchemePKCS1v15, models.HashSHA256},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		signature, err := rsaService.Sign(keys.PrivateKey, []byte(message), tc.scheme, tc.hash)
+		if err != nil {
+			t.Fatalf("Sign(%s, %s) failed: %v", tc.scheme, tc.hash, err)
+		}
+
+		valid, err := rsaService.Verify(keys.PublicKey, []byte(message), signature, tc.scheme, tc.hash)
+		if err != nil {
+			t.Fatalf("Verify(%s, %s) failed: %v", tc.scheme, tc.hash, err)
+		}
+		if !valid {
+			t.Errorf("Signature with %s/%s should be valid", tc.scheme, tc.hash)
+		}
+
+		valid, err = rsaService.Verify(keys.PublicKey, []byte(message+"!"), signature, tc.scheme, tc.hash)
+		if err != nil {
+			t.Fatalf("Verify(%s, %s) of modified message failed: %v", tc.scheme, tc.hash, err)
+		}
+		if valid {
+			t.Errorf("Signature with %s/%s should not be valid for a modified message", tc.scheme, tc.hash)
+		}
+	}
+}
+
+func TestRsaService_Sign_UnsupportedOptions(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	if _, err := rsaService.Sign(keys.PrivateKey, []byte("msg"), "dsa", models.HashSHA256); !errors.Is(err, processors.ErrUnsupportedSignatureScheme) {
+		t.Errorf("Expected unsupported scheme error, got: %v", err)
+	}
+	if _, err := rsaService.Sign(keys.PrivateKey, []byte("msg"), models.SignatureSchemePSS, "MD5"); !errors.Is(err, processors.ErrUnsupportedHash) {
+		t.Errorf("Expected unsupported hash error, got: %v", err)
+	}
+}
+
+func TestRsaService_HybridEncryptDecrypt(t *testing.T) {
+	originalMessage := strings.Repeat("A payload well beyond the RSA-OAEP size limit. ", 100)
+
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	if _, err := rsaService.Encrypt(keys.PublicKey, []byte(originalMessage)); err == nil {
+		t.Fatal("Plain RSA-OAEP encryption should reject a payload of this size")
+	}
+
+	envelope, err := rsaService.HybridEncrypt(keys.PublicKey, []byte(originalMessage))
+	if err != nil {
+		t.Fatalf("HybridEncrypt failed: %v", err)
+	}
+	if envelope.Algorithm != models.HybridAlgorithm {
+		t.Errorf("Expected algorithm %s, got %s", models.HybridAlgorithm, envelope.Algorithm)
+	}
+
+	decryptedMessage, err := rsaService.HybridDecrypt(keys.PrivateKey, envelope)
+	if err != nil {
+		t.Fatalf("HybridDecrypt failed: %v", err)
+	}
+	if string(decryptedMessage) != originalMessage {
+		t.Error("Hybrid decryption mismatch")
+	}
+
+	compact, err := processors.MarshalHybridEnvelope(envelope)
+	if err != nil {
+		t.Fatalf("MarshalHybridEnvelope failed: %v", err)
+	}
+	parsed, err := processors.UnmarshalHybridEnvelope(compact)
+	if err != nil {
+		t.Fatalf("UnmarshalHybridEnvelope failed: %v", err)
+	}
+	if parsed != envelope {
+		t.Errorf("Binary envelope roundtrip mismatch:\nExpected: %+v\nActual: %+v", envelope, parsed)
+	}
+}
+
+func TestRsaService_HybridDecrypt_Tampered(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	envelope, err := rsaService.HybridEncrypt(keys.PublicKey, []byte("Integrity protected"))
+	if err != nil {
+		t.Fatalf("HybridEncrypt failed: %v", err)
+	}
+
+	other, err := rsaService.HybridEncrypt(keys.PublicKey, []byte("Another payload"))
+	if err != nil {
+		t.Fatalf("HybridEncrypt failed: %v", err)
+	}
+	envelope.CipherText = other.CipherText
+
+	if _, err := rsaService.HybridDecrypt(keys.PrivateKey, envelope); !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected authentication failure for a swapped ciphertext, got: %v", err)
+	}
+}
+
+func TestRsaService_HybridDecrypt_InvalidEnvelope(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	otherKeys, err := rsaService.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	envelope, err := rsaService.HybridEncrypt(keys.PublicKey, []byte("Envelope"))
+	if err != nil {
+		t.Fatalf("HybridEncrypt failed: %v", err)
+	}
+
+	malformed := map[string]func(e *models.HybridEnvelope){
+		"unknown version":   func(e *models.HybridEnvelope) { e.Version = 9 },
+		"unknown algorithm": func(e *models.HybridEnvelope) { e.Algorithm = "RSA1_5" },
+		"bad key encoding":  func(e *models.HybridEnvelope) { e.EncryptedKey = "not base64!" },
+		"short nonce":       func(e *models.HybridEnvelope) { e.Nonce = "AAAA" },
+		"bad ciphertext":    func(e *models.HybridEnvelope) { e.CipherText = "not base64!" },
+	}
+	for name, mutate := range malformed {
+		tampered := envelope
+		mutate(&tampered)
+		if _, err := rsaService.HybridDecrypt(keys.PrivateKey, tampered); !errors.Is(err, processors.ErrInvalidHybridEnvelope) {
+			t.Errorf("%s: expected ErrInvalidHybridEnvelope, got: %v", name, err)
+		}
+	}
+
+	if _, err := rsaService.HybridDecrypt(otherKeys.PrivateKey, envelope); !errors.Is(err, processors.ErrInvalidHybridEnvelope) {
+		t.Errorf("Expected ErrInvalidHybridEnvelope for the wrong private key, got: %v", err)
+	}
+	if _, err := processors.UnmarshalHybridEnvelope([]byte("XX")); !errors.Is(err, processors.ErrInvalidHybridEnvelope) {
+		t.Errorf("Expected ErrInvalidHybridEnvelope for a foreign binary envelope, got: %v", err)
+	}
+}
+
+func TestRsaService_KeyPolicy(t *testing.T) {
+	keys, err := rsaService.GenerateCryptoKeysWithParams(3072, processors.RsaPublicExponent)
+	if err != nil {
+		t.Fatalf("GenerateCryptoKeysWithParams failed unexpectedly: %v", err)
+	}
+	if keys.KeySize != 3072 {
+		t.Errorf("Expected a 3072-bit key, got %d", keys.KeySize)
+	}
+
+	if _, err := rsaService.GenerateCryptoKeysWithParams(1024, 0); !errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		t.Errorf("Expected policy violation for a 1024-bit key, got: %v", err)
+	}
+	if _, err := rsaService.GenerateCryptoKeysWithParams(0, 3); !errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		t.Errorf("Expected policy violation for exponent 3, got: %v", err)
+	}
+}
+
+func TestRsaService_RejectsKeysBelowMinimum(t *testing.T) {
+	weak := processors.NewRsaService(processors.RsaKeyPolicy{DefaultBits: 1024, AllowedBits: []int{1024}, MinBits: 1024})
+	keys, err := weak.GenerateCryptoKeys()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+
+	if _, err := rsaService.Encrypt(keys.PublicKey, []byte("secret")); !errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		t.Errorf("Expected Encrypt to reject a 1024-bit key, got: %v", err)
+	}
+
+	signature, err := weak.Sign(keys.PrivateKey, []byte("message"), "", "")
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := rsaService.Verify(keys.PublicKey, []byte("message"), signature, "", ""); !errors.Is(err, processors.ErrRsaKeyPolicyViolation) {
+		t.Errorf("Expected Verify to reject a 1024-bit key, got: %v", err)
 	}
 }
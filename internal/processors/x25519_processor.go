@@ -0,0 +1,226 @@
+package processors
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Sealed box layout: version (1) || cipher id (1) || ephemeral public key (32) || ciphertext+tag.
+// The AEAD key and nonce are derived with HKDF-SHA256 from the X25519 shared secret,
+// salted with ephemeral || recipient public keys; the header is authenticated as
+// associated data. Every box uses a fresh ephemeral key, so key/nonce pairs never repeat.
+const (
+	sealedBoxVersion   byte = 1
+	sealedBoxHeaderLen      = 2 + 32
+	sealedBoxInfo           = "laba6 x25519 sealed box v1"
+)
+
+var (
+	// ErrUnsupportedSealedBoxCipher is returned for an unknown sealed box AEAD.
+	ErrUnsupportedSealedBoxCipher = errors.New("unsupported sealed box cipher")
+	// ErrInvalidSealedBox is returned for a sealed box that is not valid base64 or is malformed.
+	ErrInvalidSealedBox = errors.New("invalid sealed box")
+	// ErrInvalidX25519Key is returned for a key PEM that cannot be parsed.
+	ErrInvalidX25519Key = errors.New("invalid X25519 key")
+)
+
+var sealedBoxCipherIDs = map[models.SealedBoxCipher]byte{
+	models.SealedBoxAES256GCM:        1,
+	models.SealedBoxChaCha20Poly1305: 2,
+}
+
+// IX25519Service defines X25519 key generation and sealed-box encryption.
+type IX25519Service interface {
+	GenerateKeyPair() (models.KeyPair, error)
+	Seal(publicKey string, plaintext []byte, aead models.SealedBoxCipher) (string, error)
+	Open(privateKey, sealedBase64 string) ([]byte, error)
+}
+
+type X25519Service struct{}
+
+func NewX25519Service() *X25519Service {
+	return &X25519Service{}
+}
+
+// GenerateKeyPair generates an X25519 key pair encoded as PKCS#8 / PKIX PEM.
+func (s *X25519Service) GenerateKeyPair() (models.KeyPair, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	privatePEM, publicPEM, err := encodeKeyPairPEM(privateKey, privateKey.PublicKey())
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+
+	return models.KeyPair{
+		Algorithm:  models.KeyAlgorithmX25519,
+		KeySize:    256,
+		PrivateKey: privatePEM,
+		PublicKey:  publicPEM,
+	}, nil
+}
+
+// Seal encrypts plaintext to the recipient's public key. Only the holder of the
+// matching private key can open the box; the sender stays anonymous.
+// An empty aead defaults to AES-256-GCM.
+func (s *X25519Service) Seal(publicKeyPEM string, plaintext []byte, aead models.SealedBoxCipher) (string, error) {
+	if aead == "" {
+		aead = models.SealedBoxAES256GCM
+	}
+	cipherID, ok := sealedBoxCipherIDs[aead]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedSealedBoxCipher, aead)
+	}
+
+	recipient, err := parseX25519PublicKey(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", fmt.Errorf("key agreement failed: %w", err)
+	}
+
+	header := make([]byte, 0, sealedBoxHeaderLen)
+	header = append(header, sealedBoxVersion, cipherID)
+	header = append(header, ephemeral.PublicKey().Bytes()...)
+
+	sealer, nonce, err := sealedBoxAEAD(cipherID, shared, ephemeral.PublicKey().Bytes(), recipient.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	box := sealer.Seal(header, nonce, plaintext, header)
+	return base64.StdEncoding.EncodeToString(box), nil
+}
+
+// Open decrypts a sealed box with the recipient's private key.
+func (s *X25519Service) Open(privateKeyPEM, sealedBase64 string) ([]byte, error) {
+	privateKey, err := parseX25519PrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	box, err := base64.StdEncoding.DecodeString(sealedBase64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode base64: %v", ErrInvalidSealedBox, err)
+	}
+	if len(box) < sealedBoxHeaderLen {
+		return nil, fmt.Errorf("%w: sealed box is too short", ErrInvalidSealedBox)
+	}
+	if box[0] != sealedBoxVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSealedBox, box[0])
+	}
+
+	header := box[:sealedBoxHeaderLen]
+	ephemeralBytes := header[2:]
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ephemeral public key: %v", ErrInvalidSealedBox, err)
+	}
+	shared, err := privateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key agreement failed: %v", ErrInvalidSealedBox, err)
+	}
+
+	opener, nonce, err := sealedBoxAEAD(box[1], shared, ephemeralBytes, privateKey.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := opener.Open(nil, nonce, box[sealedBoxHeaderLen:], header)
+	if err != nil {
+		return nil, ErrAesAuthenticationFailed
+	}
+	return plaintext, nil
+}
+
+// sealedBoxAEAD derives the per-box AEAD and nonce from the shared secret.
+func sealedBoxAEAD(cipherID byte, shared, ephemeralPublic, recipientPublic []byte) (cipher.AEAD, []byte, error) {
+	const keySize, nonceSize = 32, 12
+
+	salt := append(append([]byte{}, ephemeralPublic...), recipientPublic...)
+	info := append([]byte(sealedBoxInfo), cipherID)
+
+	material, err := hkdf.Key(sha256.New, shared, salt, string(info), keySize+nonceSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	key, nonce := material[:keySize], material[keySize:]
+
+	var aead cipher.AEAD
+	switch cipherID {
+	case sealedBoxCipherIDs[models.SealedBoxAES256GCM]:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		}
+		aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create GCM: %w", err)
+		}
+	case sealedBoxCipherIDs[models.SealedBoxChaCha20Poly1305]:
+		aead, err = chacha20poly1305.New(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create ChaCha20-Poly1305: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: id %d", ErrUnsupportedSealedBoxCipher, cipherID)
+	}
+
+	return aead, nonce, nil
+}
+
+func parseX25519PublicKey(publicKeyPEM string) (*ecdh.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode public key PEM block", ErrInvalidX25519Key)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse public key: %v", ErrInvalidX25519Key, err)
+	}
+	pub, ok := key.(*ecdh.PublicKey)
+	if !ok || pub.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("%w: key is not an X25519 public key", ErrUnsupportedKeyAlgorithm)
+	}
+	return pub, nil
+}
+
+func parseX25519PrivateKey(privateKeyPEM string) (*ecdh.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode private key PEM block", ErrInvalidX25519Key)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse private key: %v", ErrInvalidX25519Key, err)
+	}
+	priv, ok := key.(*ecdh.PrivateKey)
+	if !ok || priv.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("%w: key is not an X25519 private key", ErrUnsupportedKeyAlgorithm)
+	}
+	return priv, nil
+}
@@ -0,0 +1,99 @@
+package processors_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"laba6/internal/models"
+	"laba6/internal/processors"
+	"testing"
+)
+
+var x25519Service = processors.NewX25519Service()
+
+func TestX25519Service_SealOpen(t *testing.T) {
+	const originalMessage = "Sealed for the server only"
+
+	pair, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	if pair.Algorithm != models.KeyAlgorithmX25519 {
+		t.Errorf("Expected algorithm %s, got %s", models.KeyAlgorithmX25519, pair.Algorithm)
+	}
+
+	for _, aead := range []models.SealedBoxCipher{models.SealedBoxAES256GCM, models.SealedBoxChaCha20Poly1305, ""} {
+		sealed, err := x25519Service.Seal(pair.PublicKey, []byte(originalMessage), aead)
+		if err != nil {
+			t.Fatalf("Seal with %q failed: %v", aead, err)
+		}
+
+		opened, err := x25519Service.Open(pair.PrivateKey, sealed)
+		if err != nil {
+			t.Fatalf("Open with %q failed: %v", aead, err)
+		}
+		if string(opened) != originalMessage {
+			t.Errorf("Sealed box mismatch:\nExpected: %s\nActual: %s", originalMessage, opened)
+		}
+	}
+}
+
+func TestX25519Service_Open_WrongKeyOrTampered(t *testing.T) {
+	pair, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	other, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate second key pair: %v", err)
+	}
+
+	sealed, err := x25519Service.Seal(pair.PublicKey, []byte("secret"), models.SealedBoxChaCha20Poly1305)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	if _, err := x25519Service.Open(other.PrivateKey, sealed); !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected authentication failure with the wrong key, got: %v", err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+	raw[1] = 1 // claim AES-256-GCM although the box was sealed with ChaCha20-Poly1305
+	if _, err := x25519Service.Open(pair.PrivateKey, base64.StdEncoding.EncodeToString(raw)); !errors.Is(err, processors.ErrAesAuthenticationFailed) {
+		t.Errorf("Expected authentication failure for a modified header, got: %v", err)
+	}
+
+	if _, err := x25519Service.Seal(pair.PublicKey, []byte("secret"), "Salsa20"); !errors.Is(err, processors.ErrUnsupportedSealedBoxCipher) {
+		t.Errorf("Expected unsupported cipher error, got: %v", err)
+	}
+}
+
+func TestX25519Service_MalformedInput(t *testing.T) {
+	pair, err := x25519Service.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("Setup failed: Could not generate keys: %v", err)
+	}
+	sealed, err := x25519Service.Seal(pair.PublicKey, []byte("secret"), "")
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+	raw[0] = 9
+
+	boxes := map[string]string{
+		"not base64":      "not base64!",
+		"too short":       base64.StdEncoding.EncodeToString(raw[:10]),
+		"unknown version": base64.StdEncoding.EncodeToString(raw),
+	}
+	for name, box := range boxes {
+		if _, err := x25519Service.Open(pair.PrivateKey, box); !errors.Is(err, processors.ErrInvalidSealedBox) {
+			t.Errorf("%s: expected ErrInvalidSealedBox, got: %v", name, err)
+		}
+	}
+
+	if _, err := x25519Service.Open("not a PEM", sealed); !errors.Is(err, processors.ErrInvalidX25519Key) {
+		t.Errorf("Expected ErrInvalidX25519Key for a malformed private key, got: %v", err)
+	}
+	if _, err := x25519Service.Seal("not a PEM", []byte("secret"), ""); !errors.Is(err, processors.ErrInvalidX25519Key) {
+		t.Errorf("Expected ErrInvalidX25519Key for a malformed public key, got: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+)
+
+type IAesKeyStorage interface {
+	SaveAesKey(key models.AesKey) (models.AesKeyMetadata, error)
+	GetAesKey(keyID string) (models.AesKey, models.AesKeyMetadata, error)
+	GetAesKeyMetadata(keyID string) (models.AesKeyMetadata, error)
+	ListAesKeys() ([]models.AesKeyMetadata, error)
+	DisableAesKey(keyID string) (models.AesKeyMetadata, error)
+}
+
+type PostgresAesKeyStorage struct {
+	DB      *sql.DB
+	Wrapper IKeyWrapper
+}
+
+func NewPostgresAesKeyStorage(db *sql.DB, wrapper IKeyWrapper) *PostgresAesKeyStorage {
+	return &PostgresAesKeyStorage{DB: db, Wrapper: wrapper}
+}
+
+// newKeyID returns an opaque, random identifier for a stored key.
+func newKeyID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func (s *PostgresAesKeyStorage) SaveAesKey(key models.AesKey) (models.AesKeyMetadata, error) {
+	keyID, err := newKeyID()
+	if err != nil {
+		return models.AesKeyMetadata{}, err
+	}
+
+	wrappedKey, kekVersion, err := s.Wrapper.Wrap([]byte(key.Key), kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.AesKeyMetadata{}, fmt.Errorf("failed to wrap AES key: %w", err)
+	}
+
+	query := `INSERT INTO secret_keys (id, algorithm, key_material, iv, kek_version) VALUES ($1, 'AES', $2, $3, $4)
+			  RETURNING id, status, created_at, disabled_at`
+
+	var meta models.AesKeyMetadata
+	err = s.DB.QueryRowContext(context.Background(), query, keyID, wrappedKey, key.IV, kekVersion).
+		Scan(&meta.KeyID, &meta.Status, &meta.CreatedAt, &meta.DisabledAt)
+	if err != nil {
+		return models.AesKeyMetadata{}, fmt.Errorf("failed to insert AES key into postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+func (s *PostgresAesKeyStorage) GetAesKey(keyID string) (models.AesKey, models.AesKeyMetadata, error) {
+	query := `SELECT key_material, iv, kek_version, id, status, created_at, disabled_at FROM secret_keys WHERE id = $1 AND algorithm = 'AES'`
+
+	var key models.AesKey
+	var kekVersion int
+	var meta models.AesKeyMetadata
+	err := s.DB.QueryRowContext(context.Background(), query, keyID).
+		Scan(&key.Key, &key.IV, &kekVersion, &meta.KeyID, &meta.Status, &meta.CreatedAt, &meta.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AesKey{}, models.AesKeyMetadata{}, fmt.Errorf("AES key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.AesKey{}, models.AesKeyMetadata{}, fmt.Errorf("failed to retrieve AES key from postgres: %w", err)
+	}
+
+	material, err := s.Wrapper.Unwrap(key.Key, kekVersion, kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.AesKey{}, models.AesKeyMetadata{}, fmt.Errorf("failed to unwrap AES key %s: %w", keyID, err)
+	}
+	key.Key = string(material)
+
+	return key, meta, nil
+}
+
+func (s *PostgresAesKeyStorage) GetAesKeyMetadata(keyID string) (models.AesKeyMetadata, error) {
+	query := `SELECT id, status, created_at, disabled_at FROM secret_keys WHERE id = $1 AND algorithm = 'AES'`
+
+	var meta models.AesKeyMetadata
+	err := s.DB.QueryRowContext(context.Background(), query, keyID).
+		Scan(&meta.KeyID, &meta.Status, &meta.CreatedAt, &meta.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AesKeyMetadata{}, fmt.Errorf("AES key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.AesKeyMetadata{}, fmt.Errorf("failed to retrieve AES key metadata from postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+func (s *PostgresAesKeyStorage) ListAesKeys() ([]models.AesKeyMetadata, error) {
+	query := `SELECT id, status, created_at, disabled_at FROM secret_keys WHERE algorithm = 'AES' ORDER BY created_at, id`
+
+	rows, err := s.DB.QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AES keys from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.AesKeyMetadata, 0)
+	for rows.Next() {
+		var meta models.AesKeyMetadata
+		if err := rows.Scan(&meta.KeyID, &meta.Status, &meta.CreatedAt, &meta.DisabledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan AES key metadata: %w", err)
+		}
+		keys = append(keys, meta)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *PostgresAesKeyStorage) DisableAesKey(keyID string) (models.AesKeyMetadata, error) {
+	query := `UPDATE secret_keys
+			  SET status = 'disabled', disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP)
+			  WHERE id = $1 AND algorithm = 'AES'
+			  RETURNING id, status, created_at, disabled_at`
+
+	var meta models.AesKeyMetadata
+	err := s.DB.QueryRowContext(context.Background(), query, keyID).
+		Scan(&meta.KeyID, &meta.Status, &meta.CreatedAt, &meta.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AesKeyMetadata{}, fmt.Errorf("AES key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.AesKeyMetadata{}, fmt.Errorf("failed to disable AES key in postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+// RewrapKeys re-encrypts every secret key (AES and HMAC) that is not wrapped with
+// the current KEK version.
+func (s *PostgresAesKeyStorage) RewrapKeys(ctx context.Context) (int, error) {
+	return rewrapColumn(ctx, s.DB, s.Wrapper, "secret_keys", "key_material")
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrCertificateAuthorityExists is returned when the name or CA certificate is already registered.
+var ErrCertificateAuthorityExists = errors.New("certificate authority already exists")
+
+type ICertificateAuthorityStorage interface {
+	SaveCertificateAuthority(ca models.CertificateAuthority) (models.CertificateAuthority, error)
+	GetCertificateAuthority(id int) (models.CertificateAuthority, error)
+	ListCertificateAuthorities() ([]models.CertificateAuthority, error)
+	NextCrlNumber(id int) (int64, error)
+	SaveCrl(id int, number int64, crl []byte) error
+	GetCrl(id int) ([]byte, error)
+}
+
+type PostgresCertificateAuthorityStorage struct {
+	DB *sql.DB
+}
+
+func NewPostgresCertificateAuthorityStorage(db *sql.DB) *PostgresCertificateAuthorityStorage {
+	return &PostgresCertificateAuthorityStorage{DB: db}
+}
+
+const certificateAuthorityQuery = `SELECT ca.id, ca.name, ca.key_pair_id, ca.certificate_id, ca.parent_id, ca.allowed_domains,
+	ca.allow_ip_addresses, ca.allow_subordinate_cas, ca.max_validity_days, c.certificate_pem, ca.crl_number, ca.created_at
+	FROM certificate_authorities ca JOIN certificates c ON c.id = ca.certificate_id`
+
+func scanCertificateAuthority(row rowScanner, ca *models.CertificateAuthority) error {
+	return row.Scan(&ca.ID, &ca.Name, &ca.KeyPairID, &ca.CertificateID, &ca.ParentID, pq.Array(&ca.Policy.AllowedDomains),
+		&ca.Policy.AllowIPAddresses, &ca.Policy.AllowSubordinateCAs, &ca.Policy.MaxValidityDays, &ca.CertificatePEM,
+		&ca.CrlNumber, &ca.CreatedAt)
+}
+
+func (s *PostgresCertificateAuthorityStorage) SaveCertificateAuthority(ca models.CertificateAuthority) (models.CertificateAuthority, error) {
+	query := `INSERT INTO certificate_authorities (name, key_pair_id, certificate_id, parent_id, allowed_domains,
+			  allow_ip_addresses, allow_subordinate_cas, max_validity_days)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  RETURNING id`
+
+	domains := ca.Policy.AllowedDomains
+	if domains == nil {
+		domains = []string{}
+	}
+
+	var id int
+	err := s.DB.QueryRowContext(context.Background(), query,
+		ca.Name, ca.KeyPairID, ca.CertificateID, ca.ParentID, pq.Array(domains),
+		ca.Policy.AllowIPAddresses, ca.Policy.AllowSubordinateCAs, ca.Policy.MaxValidityDays,
+	).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.CertificateAuthority{}, fmt.Errorf("%w: %s", ErrCertificateAuthorityExists, pqErr.Detail)
+		}
+		return models.CertificateAuthority{}, fmt.Errorf("failed to insert certificate authority into postgres: %w", err)
+	}
+
+	return s.GetCertificateAuthority(id)
+}
+
+func (s *PostgresCertificateAuthorityStorage) GetCertificateAuthority(id int) (models.CertificateAuthority, error) {
+	query := certificateAuthorityQuery + ` WHERE ca.id = $1`
+
+	var ca models.CertificateAuthority
+	err := scanCertificateAuthority(s.DB.QueryRowContext(context.Background(), query, id), &ca)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CertificateAuthority{}, fmt.Errorf("certificate authority with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.CertificateAuthority{}, fmt.Errorf("failed to retrieve certificate authority from postgres: %w", err)
+	}
+
+	return ca, nil
+}
+
+func (s *PostgresCertificateAuthorityStorage) ListCertificateAuthorities() ([]models.CertificateAuthority, error) {
+	rows, err := s.DB.QueryContext(context.Background(), certificateAuthorityQuery+` ORDER BY ca.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificate authorities from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	authorities := make([]models.CertificateAuthority, 0)
+	for rows.Next() {
+		var ca models.CertificateAuthority
+		if err := scanCertificateAuthority(rows, &ca); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate authority: %w", err)
+		}
+		authorities = append(authorities, ca)
+	}
+
+	return authorities, rows.Err()
+}
+
+// NextCrlNumber atomically increments and returns the CRL number of a CA.
+func (s *PostgresCertificateAuthorityStorage) NextCrlNumber(id int) (int64, error) {
+	query := `UPDATE certificate_authorities SET crl_number = crl_number + 1 WHERE id = $1 RETURNING crl_number`
+
+	var number int64
+	err := s.DB.QueryRowContext(context.Background(), query, id).Scan(&number)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("certificate authority with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return 0, fmt.Errorf("failed to update CRL number in postgres: %w", err)
+	}
+
+	return number, nil
+}
+
+// SaveCrl stores a signed CRL as the published CRL of a CA, unless a CRL with a
+// higher number was issued in the meantime.
+func (s *PostgresCertificateAuthorityStorage) SaveCrl(id int, number int64, crl []byte) error {
+	query := `UPDATE certificate_authorities SET crl = $3 WHERE id = $1 AND crl_number = $2`
+
+	if _, err := s.DB.ExecContext(context.Background(), query, id, number, crl); err != nil {
+		return fmt.Errorf("failed to store CRL in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetCrl returns the published DER encoded CRL of a CA, or nil if none was issued yet.
+func (s *PostgresCertificateAuthorityStorage) GetCrl(id int) ([]byte, error) {
+	query := `SELECT crl FROM certificate_authorities WHERE id = $1`
+
+	var crl []byte
+	err := s.DB.QueryRowContext(context.Background(), query, id).Scan(&crl)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("certificate authority with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("failed to retrieve CRL from postgres: %w", err)
+	}
+
+	return crl, nil
+}
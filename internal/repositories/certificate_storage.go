@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+)
+
+// ErrInvalidCertificateState is returned when a certificate cannot be revoked.
+var ErrInvalidCertificateState = errors.New("invalid certificate state")
+
+type ICertificateStorage interface {
+	SaveCertificate(cert models.Certificate) (models.Certificate, error)
+	GetCertificate(id int) (models.Certificate, error)
+	ListCertificates(keyPairID int) ([]models.Certificate, error)
+	ListIssuedCertificates(caID int, revokedOnly bool) ([]models.Certificate, error)
+	RevokeCertificate(id int, reason int) (models.Certificate, error)
+}
+
+type PostgresCertificateStorage struct {
+	DB *sql.DB
+}
+
+func NewPostgresCertificateStorage(db *sql.DB) *PostgresCertificateStorage {
+	return &PostgresCertificateStorage{DB: db}
+}
+
+const certificateColumns = `id, key_pair_id, issuer_ca_id, serial_number, subject, issuer, not_before, not_after, is_ca, certificate_pem,
+	revoked_at, revocation_reason, created_at`
+
+func scanCertificate(row rowScanner, cert *models.Certificate) error {
+	return row.Scan(&cert.ID, &cert.KeyPairID, &cert.IssuerCaID, &cert.SerialNumber, &cert.Subject, &cert.Issuer,
+		&cert.NotBefore, &cert.NotAfter, &cert.IsCA, &cert.CertificatePEM, &cert.RevokedAt, &cert.RevocationReason, &cert.CreatedAt)
+}
+
+func (s *PostgresCertificateStorage) SaveCertificate(cert models.Certificate) (models.Certificate, error) {
+	query := `INSERT INTO certificates (key_pair_id, issuer_ca_id, serial_number, subject, issuer, not_before, not_after, is_ca, certificate_pem)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING ` + certificateColumns
+
+	var saved models.Certificate
+	err := scanCertificate(s.DB.QueryRowContext(context.Background(), query,
+		cert.KeyPairID, cert.IssuerCaID, cert.SerialNumber, cert.Subject, cert.Issuer, cert.NotBefore, cert.NotAfter, cert.IsCA, cert.CertificatePEM,
+	), &saved)
+	if err != nil {
+		return models.Certificate{}, fmt.Errorf("failed to insert certificate into postgres: %w", err)
+	}
+
+	return saved, nil
+}
+
+func (s *PostgresCertificateStorage) GetCertificate(id int) (models.Certificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM certificates WHERE id = $1`
+
+	var cert models.Certificate
+	err := scanCertificate(s.DB.QueryRowContext(context.Background(), query, id), &cert)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Certificate{}, fmt.Errorf("certificate with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.Certificate{}, fmt.Errorf("failed to retrieve certificate from postgres: %w", err)
+	}
+
+	return cert, nil
+}
+
+// ListCertificates returns the certificates issued for a key pair, newest first.
+func (s *PostgresCertificateStorage) ListCertificates(keyPairID int) ([]models.Certificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM certificates WHERE key_pair_id = $1 ORDER BY created_at DESC, id DESC`
+	return s.queryCertificates(query, keyPairID)
+}
+
+// ListIssuedCertificates returns the registry of certificates signed by a CA, newest first.
+func (s *PostgresCertificateStorage) ListIssuedCertificates(caID int, revokedOnly bool) ([]models.Certificate, error) {
+	query := `SELECT ` + certificateColumns + ` FROM certificates
+			  WHERE issuer_ca_id = $1 AND ($2 = FALSE OR revoked_at IS NOT NULL)
+			  ORDER BY created_at DESC, id DESC`
+	return s.queryCertificates(query, caID, revokedOnly)
+}
+
+// RevokeCertificate marks a CA issued certificate as revoked with an RFC 5280 reason code.
+func (s *PostgresCertificateStorage) RevokeCertificate(id int, reason int) (models.Certificate, error) {
+	query := `UPDATE certificates SET revoked_at = CURRENT_TIMESTAMP, revocation_reason = $1
+			  WHERE id = $2 AND issuer_ca_id IS NOT NULL AND revoked_at IS NULL
+			  RETURNING ` + certificateColumns
+
+	var cert models.Certificate
+	err := scanCertificate(s.DB.QueryRowContext(context.Background(), query, reason, id), &cert)
+	if err == nil {
+		return cert, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.Certificate{}, fmt.Errorf("failed to revoke certificate in postgres: %w", err)
+	}
+
+	current, err := s.GetCertificate(id)
+	if err != nil {
+		return models.Certificate{}, err
+	}
+	if current.IssuerCaID == nil {
+		return models.Certificate{}, fmt.Errorf("%w: certificate %d was not issued by an internal CA", ErrInvalidCertificateState, id)
+	}
+	return models.Certificate{}, fmt.Errorf("%w: certificate %d is already revoked", ErrInvalidCertificateState, id)
+}
+
+func (s *PostgresCertificateStorage) queryCertificates(query string, args ...any) ([]models.Certificate, error) {
+	rows, err := s.DB.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificates from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	certs := make([]models.Certificate, 0)
+	for rows.Next() {
+		var cert models.Certificate
+		if err := scanCertificate(rows, &cert); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, rows.Err()
+}
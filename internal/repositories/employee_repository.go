@@ -1,11 +1,48 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"html"
 	"laba6/internal/models"
+	"slices"
+	"strconv"
+	"strings"
 )
 
+// employeeSortColumns whitelists the columns that may appear in ORDER BY.
+var employeeSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"position":   "position",
+	"department": "department",
+	"salary":     "salary",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// employeeColumns lists the employee columns explicitly, so that derived columns such
+// as search_vector are never scanned into models.Employee.
+const employeeColumns = `id, name, position, department, salary, created_at, updated_at, version`
+
+// employeeHeadlineOptions wraps matched words in control character sentinels and
+// highlights short fields whole. The sentinels become <mark></mark> only after the
+// field text is HTML escaped, see markHighlight.
+const employeeHeadlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", HighlightAll=true`
+
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+// ErrEmployeeVersionMismatch is returned when an If-Match precondition does not
+// match the current version of an employee.
+var ErrEmployeeVersionMismatch = errors.New("employee version mismatch")
+
 type EmployeeRepository struct {
 	db *sqlx.DB
 }
@@ -14,13 +51,177 @@ func NewEmployeeRepository(db *sqlx.DB) *EmployeeRepository {
 	return &EmployeeRepository{db: db}
 }
 
-func (r *EmployeeRepository) GetAll() ([]models.Employee, error) {
-	var employees []models.Employee
-	err := r.db.Select(&employees, "SELECT * FROM employees ORDER BY id")
-	return employees, err
+// List returns up to query.Limit+1 employees matching the filters in the given order,
+// starting at query.Offset or after the sort key values in after, together with the
+// number of all matching employees. Filters, order and limits all run in SQL, so
+// department and position filters use their indexes. The count and the page are
+// read from the same snapshot.
+func (r *EmployeeRepository) List(query models.EmployeeListQuery, sort []models.EmployeeSort, after []string) ([]models.Employee, int, error) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(query.Departments) > 0 {
+		conditions = append(conditions, "department = ANY("+arg(pq.Array(query.Departments))+")")
+	}
+	if len(query.Positions) > 0 {
+		conditions = append(conditions, "position = ANY("+arg(pq.Array(query.Positions))+")")
+	}
+	if query.SalaryMin != nil {
+		conditions = append(conditions, "salary >= "+arg(*query.SalaryMin))
+	}
+	if query.SalaryMax != nil {
+		conditions = append(conditions, "salary <= "+arg(*query.SalaryMax))
+	}
+	// Timestamps are stored without time zone in UTC.
+	if query.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+arg(query.CreatedAfter.UTC()))
+	}
+	if query.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+arg(query.CreatedBefore.UTC()))
+	}
+	if query.UpdatedAfter != nil {
+		conditions = append(conditions, "updated_at >= "+arg(query.UpdatedAfter.UTC()))
+	}
+	if query.UpdatedBefore != nil {
+		conditions = append(conditions, "updated_at < "+arg(query.UpdatedBefore.UTC()))
+	}
+
+	tx, err := r.db.BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var total int
+	if err := tx.Get(&total, "SELECT COUNT(*) FROM employees"+whereClause(conditions), args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count employees: %w", err)
+	}
+
+	order := make([]string, len(sort))
+	for i, key := range sort {
+		column, ok := employeeSortColumns[key.Field]
+		if !ok {
+			return nil, 0, fmt.Errorf("cannot sort employees by %q", key.Field)
+		}
+		order[i] = column
+		if key.Descending {
+			order[i] += " DESC"
+		}
+	}
+	if after != nil {
+		placeholders := make([]string, len(after))
+		for i, value := range after {
+			placeholders[i] = arg(value)
+		}
+		conditions = append(conditions, keysetCondition(sort, placeholders))
+	}
+
+	listQuery := `SELECT ` + employeeColumns + ` FROM employees` +
+		whereClause(conditions) + ` ORDER BY ` + strings.Join(order, ", ") +
+		` LIMIT ` + arg(query.Limit+1) + ` OFFSET ` + arg(query.Offset)
+
+	employees := make([]models.Employee, 0, query.Limit+1)
+	if err := tx.Select(&employees, listQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list employees: %w", err)
+	}
+
+	return employees, total, tx.Commit()
 }
 
-func (r *EmployeeRepository) Create(name, position, department string, salary float64) error {
+// Search returns up to limit+1 employees matching the tsquery, best ranked first, with
+// highlighted fields, together with the number of all matches. The match uses the GIN
+// index on search_vector.
+func (r *EmployeeRepository) Search(tsQuery string, limit, offset int) ([]models.EmployeeSearchResult, int, error) {
+	tx, err := r.db.BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM employees WHERE search_vector @@ to_tsquery('simple', $1)`
+	if err := tx.Get(&total, countQuery, tsQuery); err != nil {
+		return nil, 0, fmt.Errorf("failed to count employees: %w", err)
+	}
+
+	searchQuery := `
+        SELECT ` + employeeColumns + `,
+               ts_rank(search_vector, query) AS rank,
+               ts_headline('simple', name, query, $2) AS "highlights.name",
+               ts_headline('simple', position, query, $2) AS "highlights.position",
+               ts_headline('simple', department, query, $2) AS "highlights.department"
+        FROM employees, to_tsquery('simple', $1) AS query
+        WHERE search_vector @@ query
+        ORDER BY rank DESC, id
+        LIMIT $3 OFFSET $4
+    `
+	results := make([]models.EmployeeSearchResult, 0, limit+1)
+	if err := tx.Select(&results, searchQuery, tsQuery, employeeHeadlineOptions, limit+1, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to search employees: %w", err)
+	}
+	for i := range results {
+		h := &results[i].Highlights
+		h.Name, h.Position, h.Department = markHighlight(h.Name), markHighlight(h.Position), markHighlight(h.Department)
+	}
+
+	return results, total, tx.Commit()
+}
+
+// markHighlight HTML escapes a ts_headline snippet and turns its sentinels into
+// <mark></mark>, so stored markup in employee fields is never returned as HTML.
+func markHighlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(escaped)
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// keysetCondition selects the rows that come after the given sort key values. A
+// single direction becomes a row comparison the planner can serve from an index;
+// mixed directions expand to (a > $1) OR (a = $1 AND b < $2) OR ...
+func keysetCondition(sort []models.EmployeeSort, placeholders []string) string {
+	columns := make([]string, len(sort))
+	mixed := false
+	for i, key := range sort {
+		columns[i] = employeeSortColumns[key.Field]
+		mixed = mixed || key.Descending != sort[0].Descending
+	}
+
+	if !mixed {
+		operator := " > "
+		if sort[0].Descending {
+			operator = " < "
+		}
+		return "(" + strings.Join(columns, ", ") + ")" + operator + "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	terms := make([]string, len(sort))
+	for i, key := range sort {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = "+placeholders[j])
+		}
+		operator := " > "
+		if key.Descending {
+			operator = " < "
+		}
+		parts = append(parts, columns[i]+operator+placeholders[i])
+		terms[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+// validateEmployee checks the values of a new or patched employee.
+func validateEmployee(name, position, department string, salary float64) error {
 	// Validate salary
 	if salary < 0 {
 		return fmt.Errorf("salary cannot be negative")
@@ -36,60 +237,196 @@ func (r *EmployeeRepository) Create(name, position, department string, salary fl
 	if department == "" {
 		return fmt.Errorf("department is required")
 	}
+	return nil
+}
+
+// Create inserts an employee and returns the stored row with its id, timestamps and version.
+func (r *EmployeeRepository) Create(name, position, department string, salary float64) (*models.Employee, error) {
+	if err := validateEmployee(name, position, department, salary); err != nil {
+		return nil, err
+	}
 
 	// Check for duplicate name
 	var count int
 	checkQuery := `SELECT COUNT(*) FROM employees WHERE name = $1`
 	err := r.db.Get(&count, checkQuery, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if count > 0 {
-		return fmt.Errorf("employee with name '%s' already exists", name)
+		return nil, fmt.Errorf("employee with name '%s' already exists", name)
 	}
 
 	// Create employee
 	query := `
        INSERT INTO employees (name, position, department, salary)
        VALUES ($1, $2, $3, $4)
-    `
-	_, err = r.db.Exec(query, name, position, department, salary)
-	return err
+       RETURNING ` + employeeColumns
+	var employee models.Employee
+	if err := r.db.Get(&employee, query, name, position, department, salary); err != nil {
+		return nil, err
+	}
+	return &employee, nil
 }
+
 func (r *EmployeeRepository) GetByID(id int) (*models.Employee, error) {
 	var employee models.Employee
-	err := r.db.Get(&employee, "SELECT * FROM employees WHERE id=$1", id)
+	err := r.db.Get(&employee, "SELECT "+employeeColumns+" FROM employees WHERE id=$1", id)
 	if err != nil {
 		return nil, err
 	}
 	return &employee, nil
 }
 
-func (r *EmployeeRepository) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM employees WHERE id=$1", id)
-	return err
+// Delete removes an employee. With an If-Match precondition the employee must exist
+// in one of the expected versions.
+func (r *EmployeeRepository) Delete(id int, ifMatch models.IfMatch) error {
+	args := []any{id}
+	query := "DELETE FROM employees WHERE id=$1" + versionCondition(ifMatch, &args)
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if !ifMatch.Present {
+		return nil
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.missingOrMismatched(id)
+	}
+	return nil
 }
 
-func (r *EmployeeRepository) Update(id int, name, position, department string, salary float64) error {
+// Update overwrites an employee, after the same validation as Create, increments its
+// version and returns the stored row; the trg_employees_updated_at trigger sets
+// updated_at. With an If-Match precondition the write only happens if the stored
+// version still matches, so a concurrent update is rejected instead of silently
+// overwritten.
+func (r *EmployeeRepository) Update(id int, ifMatch models.IfMatch, name, position, department string, salary float64) (*models.Employee, error) {
+	if err := validateEmployee(name, position, department, salary); err != nil {
+		return nil, err
+	}
+
+	// Check for duplicate name among the other employees
+	var count int
+	if err := r.db.Get(&count, "SELECT COUNT(*) FROM employees WHERE name = $1 AND id <> $2", name, id); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("employee with name '%s' already exists", name)
+	}
+
+	args := []any{name, position, department, salary, id}
 	query := `
         UPDATE employees 
-        SET name=$1, position=$2, department=$3, salary=$4 
-        WHERE id=$5
-    `
-	result, err := r.db.Exec(query, name, position, department, salary, id)
+        SET name=$1, position=$2, department=$3, salary=$4, version=version+1 
+        WHERE id=$5` + versionCondition(ifMatch, &args) + `
+        RETURNING ` + employeeColumns
+
+	var employee models.Employee
+	err := r.db.Get(&employee, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, r.missingOrMismatched(id)
+	}
 	if err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+// versionCondition returns the WHERE condition for an If-Match precondition and
+// appends its argument. If-Match: * only requires the row to exist.
+func versionCondition(ifMatch models.IfMatch, args *[]any) string {
+	if !ifMatch.Present || ifMatch.Any {
+		return ""
+	}
+	*args = append(*args, pq.Array(ifMatch.Versions))
+	return " AND version = ANY($" + strconv.Itoa(len(*args)) + ")"
+}
+
+// missingOrMismatched explains why a conditional write matched no row.
+func (r *EmployeeRepository) missingOrMismatched(id int) error {
+	var exists bool
+	if err := r.db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM employees WHERE id=$1)", id); err != nil {
 		return err
 	}
+	if !exists {
+		return fmt.Errorf("employee not found")
+	}
+	return ErrEmployeeVersionMismatch
+}
 
-	rows, err := result.RowsAffected()
+// Patch locks the employee, checks the If-Match precondition, passes it to apply and
+// writes back only the columns apply changed, after the same validation as Create.
+// Locking the row keeps a concurrent update from being lost between the read and
+// the write. The trg_employees_updated_at trigger sets updated_at.
+func (r *EmployeeRepository) Patch(id int, ifMatch models.IfMatch, apply func(models.Employee) (models.Employee, error)) (*models.Employee, error) {
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	if rows == 0 {
-		return fmt.Errorf("employee not found")
+	var current models.Employee
+	err = tx.Get(&current, "SELECT "+employeeColumns+" FROM employees WHERE id=$1 FOR UPDATE", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("employee not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ifMatch.Present && !ifMatch.Any && !slices.Contains(ifMatch.Versions, current.Version) {
+		return nil, ErrEmployeeVersionMismatch
 	}
 
-	return nil
+	patched, err := apply(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEmployee(patched.Name, patched.Position, patched.Department, patched.Salary); err != nil {
+		return nil, err
+	}
+
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if patched.Name != current.Name {
+		var count int
+		if err := tx.Get(&count, "SELECT COUNT(*) FROM employees WHERE name = $1 AND id <> $2", patched.Name, id); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("employee with name '%s' already exists", patched.Name)
+		}
+		set("name", patched.Name)
+	}
+	if patched.Position != current.Position {
+		set("position", patched.Position)
+	}
+	if patched.Department != current.Department {
+		set("department", patched.Department)
+	}
+	if patched.Salary != current.Salary {
+		set("salary", patched.Salary)
+	}
+	if len(sets) == 0 {
+		return &current, tx.Commit()
+	}
+
+	args = append(args, id)
+	query := "UPDATE employees SET " + strings.Join(sets, ", ") + ", version = version + 1 WHERE id = $" +
+		strconv.Itoa(len(args)) + " RETURNING " + employeeColumns
+	var updated models.Employee
+	if err := tx.Get(&updated, query, args...); err != nil {
+		return nil, err
+	}
+	return &updated, tx.Commit()
 }
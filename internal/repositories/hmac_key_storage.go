@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+)
+
+type IHmacKeyStorage interface {
+	SaveHmacKey(key models.HmacKey) (models.HmacKeyMetadata, error)
+	GetHmacKey(keyID string) (models.HmacKey, models.HmacKeyMetadata, error)
+	GetHmacKeyMetadata(keyID string) (models.HmacKeyMetadata, error)
+	ListHmacKeys() ([]models.HmacKeyMetadata, error)
+	DisableHmacKey(keyID string) (models.HmacKeyMetadata, error)
+}
+
+// PostgresHmacKeyStorage keeps HMAC keys in secret_keys next to the AES keys,
+// wrapped with the same KEK.
+type PostgresHmacKeyStorage struct {
+	DB      *sql.DB
+	Wrapper IKeyWrapper
+}
+
+func NewPostgresHmacKeyStorage(db *sql.DB, wrapper IKeyWrapper) *PostgresHmacKeyStorage {
+	return &PostgresHmacKeyStorage{DB: db, Wrapper: wrapper}
+}
+
+const hmacKeyMetadataColumns = `id, algorithm, status, created_at, disabled_at`
+
+func scanHmacKeyMetadata(row rowScanner, meta *models.HmacKeyMetadata, extra ...any) error {
+	return row.Scan(append([]any{&meta.KeyID, &meta.Algorithm, &meta.Status, &meta.CreatedAt, &meta.DisabledAt}, extra...)...)
+}
+
+func (s *PostgresHmacKeyStorage) SaveHmacKey(key models.HmacKey) (models.HmacKeyMetadata, error) {
+	keyID, err := newKeyID()
+	if err != nil {
+		return models.HmacKeyMetadata{}, err
+	}
+
+	wrappedKey, kekVersion, err := s.Wrapper.Wrap([]byte(key.Key), kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.HmacKeyMetadata{}, fmt.Errorf("failed to wrap HMAC key: %w", err)
+	}
+
+	query := `INSERT INTO secret_keys (id, algorithm, key_material, kek_version) VALUES ($1, $2, $3, $4)
+			  RETURNING ` + hmacKeyMetadataColumns
+
+	var meta models.HmacKeyMetadata
+	err = scanHmacKeyMetadata(s.DB.QueryRowContext(context.Background(), query, keyID, key.Algorithm, wrappedKey, kekVersion), &meta)
+	if err != nil {
+		return models.HmacKeyMetadata{}, fmt.Errorf("failed to insert HMAC key into postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+func (s *PostgresHmacKeyStorage) GetHmacKey(keyID string) (models.HmacKey, models.HmacKeyMetadata, error) {
+	query := `SELECT ` + hmacKeyMetadataColumns + `, key_material, kek_version FROM secret_keys WHERE id = $1 AND algorithm LIKE 'HMAC-%'`
+
+	var meta models.HmacKeyMetadata
+	var wrappedKey string
+	var kekVersion int
+	err := scanHmacKeyMetadata(s.DB.QueryRowContext(context.Background(), query, keyID), &meta, &wrappedKey, &kekVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.HmacKey{}, models.HmacKeyMetadata{}, fmt.Errorf("HMAC key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.HmacKey{}, models.HmacKeyMetadata{}, fmt.Errorf("failed to retrieve HMAC key from postgres: %w", err)
+	}
+
+	material, err := s.Wrapper.Unwrap(wrappedKey, kekVersion, kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.HmacKey{}, models.HmacKeyMetadata{}, fmt.Errorf("failed to unwrap HMAC key %s: %w", keyID, err)
+	}
+
+	return models.HmacKey{Key: string(material), Algorithm: meta.Algorithm}, meta, nil
+}
+
+func (s *PostgresHmacKeyStorage) GetHmacKeyMetadata(keyID string) (models.HmacKeyMetadata, error) {
+	query := `SELECT ` + hmacKeyMetadataColumns + ` FROM secret_keys WHERE id = $1 AND algorithm LIKE 'HMAC-%'`
+
+	var meta models.HmacKeyMetadata
+	err := scanHmacKeyMetadata(s.DB.QueryRowContext(context.Background(), query, keyID), &meta)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.HmacKeyMetadata{}, fmt.Errorf("HMAC key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.HmacKeyMetadata{}, fmt.Errorf("failed to retrieve HMAC key metadata from postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+func (s *PostgresHmacKeyStorage) ListHmacKeys() ([]models.HmacKeyMetadata, error) {
+	query := `SELECT ` + hmacKeyMetadataColumns + ` FROM secret_keys WHERE algorithm LIKE 'HMAC-%' ORDER BY created_at, id`
+
+	rows, err := s.DB.QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HMAC keys from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.HmacKeyMetadata, 0)
+	for rows.Next() {
+		var meta models.HmacKeyMetadata
+		if err := scanHmacKeyMetadata(rows, &meta); err != nil {
+			return nil, fmt.Errorf("failed to scan HMAC key metadata: %w", err)
+		}
+		keys = append(keys, meta)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *PostgresHmacKeyStorage) DisableHmacKey(keyID string) (models.HmacKeyMetadata, error) {
+	query := `UPDATE secret_keys
+			  SET status = 'disabled', disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP)
+			  WHERE id = $1 AND algorithm LIKE 'HMAC-%'
+			  RETURNING ` + hmacKeyMetadataColumns
+
+	var meta models.HmacKeyMetadata
+	err := scanHmacKeyMetadata(s.DB.QueryRowContext(context.Background(), query, keyID), &meta)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.HmacKeyMetadata{}, fmt.Errorf("HMAC key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.HmacKeyMetadata{}, fmt.Errorf("failed to disable HMAC key in postgres: %w", err)
+	}
+
+	return meta, nil
+}
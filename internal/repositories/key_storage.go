@@ -3,28 +3,134 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"laba6/internal/models"
+	"time"
 )
 
+// ErrInvalidKeyState is returned when a key is not in a state that allows the requested change.
+var ErrInvalidKeyState = errors.New("invalid key state")
+
 type IKeyStorage interface {
 	SaveRsaKeys(keys models.RsaKeys) (int, error)
 	GetRsaPublicKey(id int) (string, error)
+	GetRsaKeys(id int) (models.RsaKeys, error)
+	SaveKeyPair(pair models.KeyPair) (int, error)
+	GetKeyPair(id int) (models.KeyPair, error)
+	GetPublicKey(id int) (models.KeyPair, error)
+	ListPublicKeys(statuses ...models.KeyStatus) ([]models.KeyPair, error)
+	UpdateKeyStatus(id int, expected, status models.KeyStatus) (models.KeyPair, error)
+	UpdateKeyValidity(id int, notBefore, expiresAt *time.Time) (models.KeyPair, error)
+	RotateKeyPair(oldID int, replacement models.KeyPair) (models.KeyPair, error)
+}
+
+// IKeyWrapper encrypts key material at rest with a versioned master key. The
+// binding ties a wrapped value to its record, see kekBinding.
+type IKeyWrapper interface {
+	CurrentVersion() int
+	Wrap(plaintext []byte, binding string) (string, int, error)
+	Unwrap(wrapped string, version int, binding string) ([]byte, error)
+}
+
+// kekBinding identifies the row a wrapped value is stored in, so that wrapped
+// blobs cannot be swapped between records or tables.
+func kekBinding(table string, id any) string {
+	return fmt.Sprintf("%s/%v", table, id)
+}
+
+// keyPairColumns lists the key_pairs columns scanned by scanKeyPair, in order.
+const keyPairColumns = `id, algorithm, key_size, status, not_before, expires_at, replaced_by, created_at, public_key, private_key <> ''`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKeyPair(row rowScanner, pair *models.KeyPair, extra ...any) error {
+	dest := []any{&pair.ID, &pair.Algorithm, &pair.KeySize, &pair.Status, &pair.NotBefore, &pair.ExpiresAt, &pair.ReplacedBy, &pair.CreatedAt, &pair.PublicKey, &pair.HasPrivateKey}
+	return row.Scan(append(dest, extra...)...)
 }
 
 type PostgresKeyStorage struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Wrapper IKeyWrapper
 }
 
-func NewPostgresKeyStorage(db *sql.DB) *PostgresKeyStorage {
-	return &PostgresKeyStorage{DB: db}
+func NewPostgresKeyStorage(db *sql.DB, wrapper IKeyWrapper) *PostgresKeyStorage {
+	return &PostgresKeyStorage{DB: db, Wrapper: wrapper}
 }
 
 func (s *PostgresKeyStorage) SaveRsaKeys(keys models.RsaKeys) (int, error) {
-	query := `INSERT INTO rsa_keys (public_key, private_key) VALUES ($1, $2) RETURNING id`
+	return s.SaveKeyPair(models.KeyPair{
+		Algorithm:  models.KeyAlgorithmRSA,
+		KeySize:    keys.KeySize,
+		PublicKey:  keys.PublicKey,
+		PrivateKey: keys.PrivateKey,
+	})
+}
+
+func (s *PostgresKeyStorage) GetRsaPublicKey(id int) (string, error) {
+	pair, err := s.GetPublicKey(id)
+	if err != nil {
+		return "", err
+	}
+	if pair.Algorithm != models.KeyAlgorithmRSA {
+		return "", fmt.Errorf("RSA key pair with ID %d not found: %w", id, sql.ErrNoRows)
+	}
+
+	return pair.PublicKey, nil
+}
+
+func (s *PostgresKeyStorage) GetRsaKeys(id int) (models.RsaKeys, error) {
+	pair, err := s.GetKeyPair(id)
+	if err != nil {
+		return models.RsaKeys{}, err
+	}
+	if pair.Algorithm != models.KeyAlgorithmRSA {
+		return models.RsaKeys{}, fmt.Errorf("RSA key pair with ID %d not found: %w", id, sql.ErrNoRows)
+	}
 
+	return models.RsaKeys{ID: pair.ID, KeySize: pair.KeySize, PublicKey: pair.PublicKey, PrivateKey: pair.PrivateKey}, nil
+}
+
+// SaveKeyPair stores a new key pair. An empty status defaults to active.
+func (s *PostgresKeyStorage) SaveKeyPair(pair models.KeyPair) (int, error) {
+	return insertKeyPair(context.Background(), s.DB, s.Wrapper, pair)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertKeyPair(ctx context.Context, db queryRower, wrapper IKeyWrapper, pair models.KeyPair) (int, error) {
+	// The id is allocated first because the wrapped private key is bound to it.
 	var id int
-	err := s.DB.QueryRowContext(context.Background(), query, keys.PublicKey, keys.PrivateKey).Scan(&id)
+	err := db.QueryRowContext(ctx, `SELECT nextval(pg_get_serial_sequence('key_pairs', 'id'))`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate key pair id: %w", err)
+	}
+
+	// Public-only key pairs keep an empty private key, like destroyed keys.
+	wrappedPrivateKey, kekVersion := "", 0
+	if pair.PrivateKey != "" {
+		wrappedPrivateKey, kekVersion, err = wrapper.Wrap([]byte(pair.PrivateKey), kekBinding("key_pairs", id))
+		if err != nil {
+			return 0, fmt.Errorf("failed to wrap private key: %w", err)
+		}
+	}
+
+	status := pair.Status
+	if status == "" {
+		status = models.KeyStatusActive
+	}
+
+	query := `INSERT INTO key_pairs (id, algorithm, key_size, status, not_before, expires_at, public_key, private_key, kek_version)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+
+	err = db.QueryRowContext(ctx, query,
+		id, pair.Algorithm, pair.KeySize, status, pair.NotBefore, pair.ExpiresAt, pair.PublicKey, wrappedPrivateKey, kekVersion,
+	).Scan(&id)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert keys into postgres: %w", err)
@@ -33,18 +139,226 @@ func (s *PostgresKeyStorage) SaveRsaKeys(keys models.RsaKeys) (int, error) {
 	return id, nil
 }
 
-func (s *PostgresKeyStorage) GetRsaPublicKey(id int) (string, error) {
-	query := `SELECT public_key FROM rsa_keys WHERE id = $1`
+// GetPublicKey returns the public half of a key pair; PrivateKey is left empty.
+func (s *PostgresKeyStorage) GetPublicKey(id int) (models.KeyPair, error) {
+	query := `SELECT ` + keyPairColumns + ` FROM key_pairs WHERE id = $1`
+
+	var pair models.KeyPair
+	err := scanKeyPair(s.DB.QueryRowContext(context.Background(), query, id), &pair)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.KeyPair{}, fmt.Errorf("key pair with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.KeyPair{}, fmt.Errorf("failed to retrieve public key from postgres: %w", err)
+	}
+
+	return pair, nil
+}
+
+func (s *PostgresKeyStorage) GetKeyPair(id int) (models.KeyPair, error) {
+	query := `SELECT ` + keyPairColumns + `, private_key, kek_version FROM key_pairs WHERE id = $1`
+
+	var pair models.KeyPair
+	var kekVersion int
+	err := scanKeyPair(s.DB.QueryRowContext(context.Background(), query, id), &pair, &pair.PrivateKey, &kekVersion)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.KeyPair{}, fmt.Errorf("key pair with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.KeyPair{}, fmt.Errorf("failed to retrieve key pair from postgres: %w", err)
+	}
+
+	privateKey, err := s.Wrapper.Unwrap(pair.PrivateKey, kekVersion, kekBinding("key_pairs", id))
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to unwrap private key %d: %w", id, err)
+	}
+	pair.PrivateKey = string(privateKey)
+
+	return pair, nil
+}
+
+// ListPublicKeys returns the public halves of all key pairs in one of the given statuses.
+func (s *PostgresKeyStorage) ListPublicKeys(statuses ...models.KeyStatus) ([]models.KeyPair, error) {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
 
-	var publicKey string
-	err := s.DB.QueryRowContext(context.Background(), query, id).Scan(&publicKey)
+	query := `SELECT ` + keyPairColumns + ` FROM key_pairs WHERE status = ANY($1) ORDER BY id`
 
+	rows, err := s.DB.QueryContext(context.Background(), query, pq.Array(values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list public keys from postgres: %w", err)
+	}
+	defer rows.Close()
+
+	pairs := make([]models.KeyPair, 0)
+	for rows.Next() {
+		var pair models.KeyPair
+		if err := scanKeyPair(rows, &pair); err != nil {
+			return nil, fmt.Errorf("failed to scan public key: %w", err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, rows.Err()
+}
+
+// UpdateKeyStatus sets a new lifecycle status if the key is still in the expected
+// status, so concurrent changes cannot bypass the transition checked by the caller.
+// Destroying a key also erases its private key material; the public key is kept
+// for auditing.
+func (s *PostgresKeyStorage) UpdateKeyStatus(id int, expected, status models.KeyStatus) (models.KeyPair, error) {
+	query := `UPDATE key_pairs
+			  SET status = $1::text,
+			      status_changed_at = CURRENT_TIMESTAMP,
+			      private_key = CASE WHEN $1::text = 'destroyed' THEN '' ELSE private_key END,
+			      kek_version = CASE WHEN $1::text = 'destroyed' THEN 0 ELSE kek_version END
+			  WHERE id = $2 AND status = $3
+			  RETURNING ` + keyPairColumns
+
+	var pair models.KeyPair
+	err := scanKeyPair(s.DB.QueryRowContext(context.Background(), query, status, id, expected), &pair)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := s.DB.QueryRowContext(context.Background(), `SELECT EXISTS (SELECT 1 FROM key_pairs WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return models.KeyPair{}, fmt.Errorf("failed to update key status in postgres: %w", err)
+		}
+		if !exists {
+			return models.KeyPair{}, fmt.Errorf("key pair with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.KeyPair{}, fmt.Errorf("%w: key pair %d is no longer %s", ErrInvalidKeyState, id, expected)
+	}
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to update key status in postgres: %w", err)
+	}
+
+	return pair, nil
+}
+
+// UpdateKeyValidity sets the not_before / expires_at window of a key pair.
+func (s *PostgresKeyStorage) UpdateKeyValidity(id int, notBefore, expiresAt *time.Time) (models.KeyPair, error) {
+	query := `UPDATE key_pairs SET not_before = $1, expires_at = $2 WHERE id = $3 RETURNING ` + keyPairColumns
+
+	var pair models.KeyPair
+	err := scanKeyPair(s.DB.QueryRowContext(context.Background(), query, notBefore, expiresAt, id), &pair)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("RSA key pair with ID %d not found: %w", id, sql.ErrNoRows)
+			return models.KeyPair{}, fmt.Errorf("key pair with ID %d not found: %w", id, sql.ErrNoRows)
+		}
+		return models.KeyPair{}, fmt.Errorf("failed to update key validity in postgres: %w", err)
+	}
+
+	return pair, nil
+}
+
+// RotateKeyPair stores the replacement as the new active key and retires the
+// old one in a single transaction. Only active keys can be rotated.
+func (s *PostgresKeyStorage) RotateKeyPair(oldID int, replacement models.KeyPair) (models.KeyPair, error) {
+	ctx := context.Background()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status models.KeyStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM key_pairs WHERE id = $1 FOR UPDATE`, oldID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.KeyPair{}, fmt.Errorf("key pair with ID %d not found: %w", oldID, sql.ErrNoRows)
+		}
+		return models.KeyPair{}, fmt.Errorf("failed to lock key pair %d: %w", oldID, err)
+	}
+	if status != models.KeyStatusActive {
+		return models.KeyPair{}, fmt.Errorf("%w: key pair %d is %s, only active keys can be rotated", ErrInvalidKeyState, oldID, status)
+	}
+
+	replacement.Status = models.KeyStatusActive
+	newID, err := insertKeyPair(ctx, tx, s.Wrapper, replacement)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE key_pairs SET status = 'retired', status_changed_at = CURRENT_TIMESTAMP, replaced_by = $1 WHERE id = $2`,
+		newID, oldID)
+	if err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to retire key pair %d: %w", oldID, err)
+	}
+
+	var pair models.KeyPair
+	if err := scanKeyPair(tx.QueryRowContext(ctx, `SELECT `+keyPairColumns+` FROM key_pairs WHERE id = $1`, newID), &pair); err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to read rotated key pair: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.KeyPair{}, fmt.Errorf("failed to commit key rotation: %w", err)
+	}
+	return pair, nil
+}
+
+// RewrapPrivateKeys re-encrypts every private key that is not wrapped with the
+// current KEK version. It is used when rotating the KEK.
+func (s *PostgresKeyStorage) RewrapPrivateKeys(ctx context.Context) (int, error) {
+	return rewrapColumn(ctx, s.DB, s.Wrapper, "key_pairs", "private_key")
+}
+
+// rewrapColumn re-encrypts the wrapped values of table.column whose kek_version
+// differs from the current one, inside a single transaction.
+func rewrapColumn(ctx context.Context, db *sql.DB, wrapper IKeyWrapper, table, column string) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	selectQuery := fmt.Sprintf(`SELECT id, %s, kek_version FROM %s WHERE kek_version <> $1 AND %s <> '' FOR UPDATE`, column, table, column)
+	rows, err := tx.QueryContext(ctx, selectQuery, wrapper.CurrentVersion())
+	if err != nil {
+		return 0, fmt.Errorf("failed to select %s for rewrap: %w", table, err)
+	}
+
+	type wrappedRow struct {
+		id         string
+		value      string
+		kekVersion int
+	}
+	var pending []wrappedRow
+	for rows.Next() {
+		var row wrappedRow
+		if err := rows.Scan(&row.id, &row.value, &row.kekVersion); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s row: %w", table, err)
 		}
-		return "", fmt.Errorf("failed to retrieve public key from postgres: %w", err)
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s rows: %w", table, err)
 	}
 
-	return publicKey, nil
+	updateQuery := fmt.Sprintf(`UPDATE %s SET %s = $1, kek_version = $2 WHERE id = $3`, table, column)
+	for _, row := range pending {
+		binding := kekBinding(table, row.id)
+		plaintext, err := wrapper.Unwrap(row.value, row.kekVersion, binding)
+		if err != nil {
+			return 0, fmt.Errorf("%s row %s: %w", table, row.id, err)
+		}
+		wrapped, version, err := wrapper.Wrap(plaintext, binding)
+		if err != nil {
+			return 0, fmt.Errorf("%s row %s: %w", table, row.id, err)
+		}
+		if _, err := tx.ExecContext(ctx, updateQuery, wrapped, version, row.id); err != nil {
+			return 0, fmt.Errorf("failed to update %s row %s: %w", table, row.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rewrap of %s: %w", table, err)
+	}
+	return len(pending), nil
 }
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"laba6/internal/models"
+)
+
+// ISecretKeyStorage keeps KDF master keys in secret_keys. Only keys created as
+// master keys can be read back; AES and HMAC working keys are not visible here.
+type ISecretKeyStorage interface {
+	SaveKdfMasterKey(key []byte) (models.KdfMasterKeyMetadata, error)
+	GetKdfMasterKey(keyID string) (models.SecretKeyMaterial, error)
+}
+
+type PostgresSecretKeyStorage struct {
+	DB      *sql.DB
+	Wrapper IKeyWrapper
+}
+
+func NewPostgresSecretKeyStorage(db *sql.DB, wrapper IKeyWrapper) *PostgresSecretKeyStorage {
+	return &PostgresSecretKeyStorage{DB: db, Wrapper: wrapper}
+}
+
+// SaveKdfMasterKey wraps and stores a new master key.
+func (s *PostgresSecretKeyStorage) SaveKdfMasterKey(key []byte) (models.KdfMasterKeyMetadata, error) {
+	keyID, err := newKeyID()
+	if err != nil {
+		return models.KdfMasterKeyMetadata{}, err
+	}
+
+	wrappedKey, kekVersion, err := s.Wrapper.Wrap([]byte(base64.StdEncoding.EncodeToString(key)), kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.KdfMasterKeyMetadata{}, fmt.Errorf("failed to wrap KDF master key: %w", err)
+	}
+
+	query := `INSERT INTO secret_keys (id, algorithm, key_material, kek_version) VALUES ($1, $2, $3, $4)
+			  RETURNING id, algorithm, status, created_at, disabled_at`
+
+	var meta models.KdfMasterKeyMetadata
+	err = s.DB.QueryRowContext(context.Background(), query, keyID, models.KdfMasterKeyAlgorithm, wrappedKey, kekVersion).
+		Scan(&meta.KeyID, &meta.Algorithm, &meta.Status, &meta.CreatedAt, &meta.DisabledAt)
+	if err != nil {
+		return models.KdfMasterKeyMetadata{}, fmt.Errorf("failed to insert KDF master key into postgres: %w", err)
+	}
+
+	return meta, nil
+}
+
+// GetKdfMasterKey unwraps and decodes a stored KDF master key.
+func (s *PostgresSecretKeyStorage) GetKdfMasterKey(keyID string) (models.SecretKeyMaterial, error) {
+	query := `SELECT id, algorithm, status, key_material, kek_version FROM secret_keys WHERE id = $1 AND algorithm = $2`
+
+	var key models.SecretKeyMaterial
+	var wrappedKey string
+	var kekVersion int
+	err := s.DB.QueryRowContext(context.Background(), query, keyID, models.KdfMasterKeyAlgorithm).
+		Scan(&key.KeyID, &key.Algorithm, &key.Status, &wrappedKey, &kekVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SecretKeyMaterial{}, fmt.Errorf("KDF master key %s not found: %w", keyID, sql.ErrNoRows)
+		}
+		return models.SecretKeyMaterial{}, fmt.Errorf("failed to retrieve KDF master key from postgres: %w", err)
+	}
+
+	material, err := s.Wrapper.Unwrap(wrappedKey, kekVersion, kekBinding("secret_keys", keyID))
+	if err != nil {
+		return models.SecretKeyMaterial{}, fmt.Errorf("failed to unwrap KDF master key %s: %w", keyID, err)
+	}
+	key.Key, err = base64.StdEncoding.DecodeString(string(material))
+	if err != nil {
+		return models.SecretKeyMaterial{}, fmt.Errorf("failed to decode KDF master key %s: %w", keyID, err)
+	}
+
+	return key, nil
+}
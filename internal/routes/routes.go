@@ -14,30 +14,89 @@ func NewRouter(engine *gin.Engine) *Router {
 }
 
 func (r *Router) SetupRoutes(h *handlers.Handler) {
+	r.engine.GET("/.well-known/jwks.json", h.Keys.GetJWKS)
+
 	apiGroup := r.engine.Group("/api")
 	{
 		cryptoKeysGroup := apiGroup.Group("/crypto-keys")
 		{
 			cryptoKeysGroup.POST("/generate/rsa-keys", h.Rsa.GenerateRsaKeys)
 			cryptoKeysGroup.GET("/rsa-public-key/:id", h.Rsa.GetRsaPublicKey)
+			cryptoKeysGroup.POST("/generate/ec-keys", h.Ec.GenerateEcKeys)
+			cryptoKeysGroup.GET("/public-key/:id", h.Keys.GetPublicKey)
+			cryptoKeysGroup.POST("/import", h.Keys.ImportKey)
+			cryptoKeysGroup.POST("/:id/export", h.Keys.ExportKey)
+			cryptoKeysGroup.POST("/:id/csr", h.Certificates.CreateCSR)
+			cryptoKeysGroup.POST("/:id/certificates/self-signed", h.Certificates.SelfSign)
+			cryptoKeysGroup.GET("/:id/certificates", h.Certificates.ListCertificates)
+			cryptoKeysGroup.POST("/:id/rotate", h.Keys.RotateKey)
+			cryptoKeysGroup.PUT("/:id/status", h.Keys.UpdateKeyStatus)
+			cryptoKeysGroup.PUT("/:id/validity", h.Keys.UpdateKeyValidity)
+		}
+
+		apiGroup.GET("/certificates/:id", h.Certificates.GetCertificate)
+		apiGroup.POST("/certificates/:id/revoke", h.Ca.RevokeCertificate)
+
+		caGroup := apiGroup.Group("/certificate-authorities")
+		{
+			caGroup.POST("", h.Ca.CreateCertificateAuthority)
+			caGroup.GET("", h.Ca.ListCertificateAuthorities)
+			caGroup.GET("/:id", h.Ca.GetCertificateAuthority)
+			caGroup.POST("/:id/sign", h.Ca.SignCSR)
+			caGroup.GET("/:id/certificates", h.Ca.ListIssuedCertificates)
+			caGroup.GET("/:id/crl", h.Ca.GetCRL)
 		}
 
 		v1 := apiGroup.Group("/v1")
 		{
 			v1.GET("/employees", h.GetEmployees)
+			v1.GET("/employees/search", h.SearchEmployees)
 			v1.GET("/employees/:id", h.GetEmployee)
 			v1.POST("/employees", h.CreateEmployee)
 			v1.PUT("/employees/:id", h.UpdateEmployee)
+			v1.PATCH("/employees/:id", h.PatchEmployee)
 			v1.DELETE("/employees/:id", h.DeleteEmployee)
 
 			cryptoTestGroup := v1.Group("/crypto")
 			{
 				cryptoTestGroup.POST("/rsa/encrypt", h.Rsa.Encrypt)
 				cryptoTestGroup.POST("/rsa/decrypt", h.Rsa.Decrypt)
+				cryptoTestGroup.POST("/rsa/sign", h.Rsa.Sign)
+				cryptoTestGroup.POST("/rsa/verify", h.Rsa.Verify)
+				cryptoTestGroup.POST("/rsa/hybrid/encrypt", h.Rsa.HybridEncrypt)
+				cryptoTestGroup.POST("/rsa/hybrid/decrypt", h.Rsa.HybridDecrypt)
+				cryptoTestGroup.POST("/rsa/:id/encrypt", h.Rsa.EncryptWithStoredKey)
+				cryptoTestGroup.POST("/rsa/:id/decrypt", h.Rsa.DecryptWithStoredKey)
+
+				cryptoTestGroup.POST("/ec/sign", h.Ec.Sign)
+				cryptoTestGroup.POST("/ec/verify", h.Ec.Verify)
+
+				cryptoTestGroup.POST("/x25519/generate", h.X25519.GenerateKeys)
+				cryptoTestGroup.POST("/x25519/seal", h.X25519.Seal)
+				cryptoTestGroup.POST("/x25519/open", h.X25519.Open)
 
 				cryptoTestGroup.POST("/aes/generate", h.Aes.GenerateKeys)
 				cryptoTestGroup.POST("/aes/encrypt", h.Aes.Encrypt)
 				cryptoTestGroup.POST("/aes/decrypt", h.Aes.Decrypt)
+				cryptoTestGroup.POST("/aes/stream/encrypt", h.Aes.EncryptStream)
+				cryptoTestGroup.POST("/aes/stream/decrypt", h.Aes.DecryptStream)
+				cryptoTestGroup.GET("/aes/keys", h.Aes.ListKeys)
+				cryptoTestGroup.GET("/aes/keys/:keyId", h.Aes.GetKeyMetadata)
+				cryptoTestGroup.POST("/aes/keys/:keyId/disable", h.Aes.DisableKey)
+
+				cryptoTestGroup.POST("/hmac/generate", h.Hmac.GenerateKeys)
+				cryptoTestGroup.POST("/hmac/sign", h.Hmac.Sign)
+				cryptoTestGroup.POST("/hmac/verify", h.Hmac.Verify)
+				cryptoTestGroup.GET("/hmac/keys", h.Hmac.ListKeys)
+				cryptoTestGroup.GET("/hmac/keys/:keyId", h.Hmac.GetKeyMetadata)
+				cryptoTestGroup.POST("/hmac/keys/:keyId/disable", h.Hmac.DisableKey)
+
+				cryptoTestGroup.POST("/password/hash", h.Passwords.Hash)
+				cryptoTestGroup.POST("/password/verify", h.Passwords.Verify)
+
+				cryptoTestGroup.POST("/kdf/master-keys", h.Kdf.GenerateMasterKey)
+				cryptoTestGroup.POST("/kdf/hkdf", h.Kdf.HKDF)
+				cryptoTestGroup.POST("/kdf/pbkdf2", h.Kdf.PBKDF2)
 			}
 		}
 	}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS aes_keys;
\ No newline at end of file
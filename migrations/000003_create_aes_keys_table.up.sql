@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS aes_keys (
+                                        id VARCHAR(64) PRIMARY KEY,
+                                        key_material TEXT NOT NULL,
+                                        iv TEXT NOT NULL,
+                                        status VARCHAR(16) NOT NULL DEFAULT 'active',
+                                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                        disabled_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS aes_keys DROP COLUMN IF EXISTS kek_version;
+ALTER TABLE IF EXISTS rsa_keys DROP COLUMN IF EXISTS kek_version;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- kek_version = 0 marks rows written before envelope encryption (plaintext).
+ALTER TABLE rsa_keys ADD COLUMN IF NOT EXISTS kek_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE aes_keys ADD COLUMN IF NOT EXISTS kek_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_key_pairs_algorithm;
+
+DO $$
+BEGIN
+    IF to_regclass('key_pairs') IS NOT NULL THEN
+        DELETE FROM key_pairs WHERE algorithm <> 'RSA';
+    END IF;
+END $$;
+
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS algorithm;
+ALTER SEQUENCE IF EXISTS key_pairs_id_seq RENAME TO rsa_keys_id_seq;
+ALTER TABLE IF EXISTS key_pairs RENAME TO rsa_keys;
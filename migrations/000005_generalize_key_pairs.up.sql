@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS rsa_keys RENAME TO key_pairs;
+ALTER SEQUENCE IF EXISTS rsa_keys_id_seq RENAME TO key_pairs_id_seq;
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS algorithm VARCHAR(32) NOT NULL DEFAULT 'RSA';
+
+CREATE INDEX IF NOT EXISTS idx_key_pairs_algorithm ON key_pairs(algorithm);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_key_pairs_status;
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS status;
\ No newline at end of file
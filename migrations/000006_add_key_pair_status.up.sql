@@ -0,0 +1,3 @@
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS idx_key_pairs_status ON key_pairs(status);
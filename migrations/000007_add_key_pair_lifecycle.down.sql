@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS key_pairs DROP CONSTRAINT IF EXISTS chk_key_pairs_status;
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS replaced_by;
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS not_before;
@@ -0,0 +1,7 @@
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS not_before TIMESTAMP WITH TIME ZONE;
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS replaced_by INTEGER REFERENCES key_pairs(id);
+
+ALTER TABLE key_pairs ADD CONSTRAINT chk_key_pairs_status
+    CHECK (status IN ('pending', 'active', 'retired', 'revoked', 'destroyed'));
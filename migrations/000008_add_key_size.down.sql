@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS key_pairs DROP COLUMN IF EXISTS key_size;
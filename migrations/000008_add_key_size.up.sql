@@ -0,0 +1,9 @@
+ALTER TABLE key_pairs ADD COLUMN IF NOT EXISTS key_size INTEGER NOT NULL DEFAULT 0;
+
+-- RSA keys were always generated with 2048 bits before the size became configurable.
+UPDATE key_pairs SET key_size = CASE algorithm
+    WHEN 'RSA' THEN 2048
+    WHEN 'ECDSA-P384' THEN 384
+    ELSE 256
+END
+WHERE key_size = 0;
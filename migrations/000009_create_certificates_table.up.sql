@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS certificates (
+                                            id SERIAL PRIMARY KEY,
+                                            key_pair_id INTEGER NOT NULL REFERENCES key_pairs(id),
+                                            serial_number VARCHAR(64) NOT NULL,
+                                            subject TEXT NOT NULL,
+                                            issuer TEXT NOT NULL,
+                                            not_before TIMESTAMP WITH TIME ZONE NOT NULL,
+                                            not_after TIMESTAMP WITH TIME ZONE NOT NULL,
+                                            is_ca BOOLEAN NOT NULL DEFAULT FALSE,
+                                            certificate_pem TEXT NOT NULL,
+                                            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                            CONSTRAINT uq_certificates_issuer_serial UNIQUE (issuer, serial_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_certificates_key_pair_id ON certificates (key_pair_id);
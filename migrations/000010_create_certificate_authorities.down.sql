@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_certificates_issuer_ca_id;
+
+DO $$
+BEGIN
+    IF to_regclass('certificates') IS NOT NULL THEN
+        DELETE FROM certificates WHERE key_pair_id IS NULL;
+    END IF;
+END $$;
+
+ALTER TABLE IF EXISTS certificates DROP COLUMN IF EXISTS revocation_reason;
+ALTER TABLE IF EXISTS certificates DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE IF EXISTS certificates DROP COLUMN IF EXISTS issuer_ca_id;
+ALTER TABLE IF EXISTS certificates ALTER COLUMN key_pair_id SET NOT NULL;
+DROP TABLE IF EXISTS certificate_authorities;
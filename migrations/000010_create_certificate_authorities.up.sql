@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS certificate_authorities (
+                                                       id SERIAL PRIMARY KEY,
+                                                       name VARCHAR(128) NOT NULL UNIQUE,
+                                                       key_pair_id INTEGER NOT NULL REFERENCES key_pairs(id),
+                                                       certificate_id INTEGER NOT NULL UNIQUE REFERENCES certificates(id),
+                                                       parent_id INTEGER REFERENCES certificate_authorities(id),
+                                                       allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+                                                       allow_ip_addresses BOOLEAN NOT NULL DEFAULT FALSE,
+                                                       allow_subordinate_cas BOOLEAN NOT NULL DEFAULT FALSE,
+                                                       max_validity_days INTEGER NOT NULL,
+                                                       crl_number BIGINT NOT NULL DEFAULT 0,
+                                                       created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Certificates issued from a CSR may belong to keys held outside the service.
+ALTER TABLE certificates ALTER COLUMN key_pair_id DROP NOT NULL;
+ALTER TABLE certificates ADD COLUMN IF NOT EXISTS issuer_ca_id INTEGER REFERENCES certificate_authorities(id);
+ALTER TABLE certificates ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE certificates ADD COLUMN IF NOT EXISTS revocation_reason INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_certificates_issuer_ca_id ON certificates (issuer_ca_id);
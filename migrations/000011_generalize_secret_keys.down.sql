@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_secret_keys_algorithm;
+
+DO $$
+BEGIN
+    IF to_regclass('secret_keys') IS NOT NULL THEN
+        DELETE FROM secret_keys WHERE algorithm <> 'AES';
+    END IF;
+END $$;
+
+ALTER TABLE IF EXISTS secret_keys ALTER COLUMN iv DROP DEFAULT;
+ALTER TABLE IF EXISTS secret_keys DROP COLUMN IF EXISTS algorithm;
+ALTER TABLE IF EXISTS secret_keys RENAME TO aes_keys;
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS aes_keys RENAME TO secret_keys;
+ALTER TABLE secret_keys ADD COLUMN IF NOT EXISTS algorithm VARCHAR(32) NOT NULL DEFAULT 'AES';
+-- Only AES keys carry an IV.
+ALTER TABLE secret_keys ALTER COLUMN iv SET DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_secret_keys_algorithm ON secret_keys(algorithm);
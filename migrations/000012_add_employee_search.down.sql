@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_employees_search;
+ALTER TABLE IF EXISTS employees DROP COLUMN IF EXISTS search_vector;
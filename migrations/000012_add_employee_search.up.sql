@@ -0,0 +1,9 @@
+-- 'simple' keeps names unstemmed; weights rank name matches above position and department.
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', position), 'B') ||
+        setweight(to_tsvector('simple', department), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_employees_search ON employees USING GIN (search_vector);
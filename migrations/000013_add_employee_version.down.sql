@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS employees DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- Incremented on every write; exposed as the ETag for optimistic concurrency control.
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
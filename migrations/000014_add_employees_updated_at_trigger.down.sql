@@ -0,0 +1,8 @@
+DO $$
+BEGIN
+    IF to_regclass('employees') IS NOT NULL THEN
+        DROP TRIGGER IF EXISTS trg_employees_updated_at ON employees;
+    END IF;
+END $$;
+
+DROP FUNCTION IF EXISTS set_updated_at();
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS certificate_authorities DROP COLUMN IF EXISTS crl;
@@ -0,0 +1,3 @@
+-- The latest signed CRL of each CA. It is reissued on revocation and before it expires,
+-- so fetching it does not consume CRL numbers.
+ALTER TABLE certificate_authorities ADD COLUMN IF NOT EXISTS crl BYTEA;
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -9,11 +10,18 @@ import (
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
+	"os"
+	"strconv"
+	"strings"
 )
 
 type Configuration struct {
 	Application ApplicationConfiguration
 	Database    DatabaseConfiguration
+	Kek         KekConfiguration
+	Rsa         RsaConfiguration
+	Password    PasswordConfiguration
+	Employees   EmployeeConfiguration
 }
 
 type ApplicationConfiguration struct {
@@ -31,12 +39,119 @@ type DatabaseConfiguration struct {
 	SSLMode  string
 }
 
-func LoadConfiguration() *Configuration {
+// RsaConfiguration is the server policy for RSA key sizes.
+type RsaConfiguration struct {
+	DefaultBits int
+	AllowedBits []int
+	MinBits     int
+}
+
+// PasswordConfiguration selects the algorithm and cost of new password hashes.
+type PasswordConfiguration struct {
+	Algorithm         string
+	Argon2MemoryKiB   int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+}
+
+// EmployeeConfiguration controls optimistic concurrency on employee writes. With
+// RequireIfMatch, PUT, PATCH and DELETE without an If-Match header are rejected.
+type EmployeeConfiguration struct {
+	RequireIfMatch bool
+}
+
+// KekConfiguration holds the master key-encryption keys used to wrap stored
+// private key material. Keys are base64-encoded 32-byte values read either
+// from the environment or from a file.
+type KekConfiguration struct {
+	Version         int
+	Key             string
+	PreviousVersion int
+	PreviousKey     string
+}
+
+// Keys decodes the configured KEKs into a version -> key map.
+func (c KekConfiguration) Keys() (map[int][]byte, error) {
+	if c.Key == "" {
+		return nil, fmt.Errorf("KEK is not configured, set KEK or KEK_FILE")
+	}
+	if c.Version <= 0 {
+		return nil, fmt.Errorf("KEK_VERSION must be positive, got %d", c.Version)
+	}
+
+	current, err := decodeKek(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEK: %w", err)
+	}
+	keys := map[int][]byte{c.Version: current}
+
+	if c.PreviousKey != "" {
+		if c.PreviousVersion <= 0 || c.PreviousVersion == c.Version {
+			return nil, fmt.Errorf("KEK_PREVIOUS_VERSION must be positive and differ from KEK_VERSION")
+		}
+		previous, err := decodeKek(c.PreviousKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous KEK: %w", err)
+		}
+		keys[c.PreviousVersion] = previous
+	}
+
+	return keys, nil
+}
+
+func decodeKek(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("expected 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// readIntList parses a comma separated list of integers such as "2048,3072,4096".
+func readIntList(v *viper.Viper, key string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(v.GetString(key), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", key, field, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// readSecret returns the value of envKey, or the contents of the file named by fileKey.
+func readSecret(v *viper.Viper, envKey, fileKey string) (string, error) {
+	if value := v.GetString(envKey); value != "" {
+		return value, nil
+	}
+	if path := v.GetString(fileKey); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", fileKey, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return "", nil
+}
+
+// LoadConfiguration reads the configuration from the environment and an optional
+// .env file. It fails when a configured secret file cannot be read or a value is malformed.
+func LoadConfiguration() (*Configuration, error) {
 	_ = godotenv.Load()
 	v := viper.New()
 	v.AutomaticEnv()
 
 	cfg := new(Configuration)
+	var err error
 
 	cfg.Application.Port = v.GetString("APP_PORT")
 	cfg.Application.RequestTimeout = v.GetInt("REQUEST_TIMEOUT")
@@ -49,7 +164,40 @@ func LoadConfiguration() *Configuration {
 	cfg.Database.Name = v.GetString("DB_NAME")
 	cfg.Database.SSLMode = v.GetString("DB_SSLMODE")
 
-	return cfg
+	v.SetDefault("KEK_VERSION", 1)
+	cfg.Kek.Version = v.GetInt("KEK_VERSION")
+	if cfg.Kek.Key, err = readSecret(v, "KEK", "KEK_FILE"); err != nil {
+		return nil, err
+	}
+	cfg.Kek.PreviousVersion = v.GetInt("KEK_PREVIOUS_VERSION")
+	if cfg.Kek.PreviousKey, err = readSecret(v, "KEK_PREVIOUS", "KEK_PREVIOUS_FILE"); err != nil {
+		return nil, err
+	}
+
+	v.SetDefault("RSA_DEFAULT_BITS", 2048)
+	v.SetDefault("RSA_ALLOWED_BITS", "2048,3072,4096")
+	v.SetDefault("RSA_MIN_BITS", 2048)
+	cfg.Rsa.DefaultBits = v.GetInt("RSA_DEFAULT_BITS")
+	if cfg.Rsa.AllowedBits, err = readIntList(v, "RSA_ALLOWED_BITS"); err != nil {
+		return nil, err
+	}
+	cfg.Rsa.MinBits = v.GetInt("RSA_MIN_BITS")
+
+	v.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	v.SetDefault("ARGON2_MEMORY_KIB", 19456)
+	v.SetDefault("ARGON2_ITERATIONS", 2)
+	v.SetDefault("ARGON2_PARALLELISM", 1)
+	v.SetDefault("BCRYPT_COST", 12)
+	cfg.Password.Algorithm = v.GetString("PASSWORD_HASH_ALGORITHM")
+	cfg.Password.Argon2MemoryKiB = v.GetInt("ARGON2_MEMORY_KIB")
+	cfg.Password.Argon2Iterations = v.GetInt("ARGON2_ITERATIONS")
+	cfg.Password.Argon2Parallelism = v.GetInt("ARGON2_PARALLELISM")
+	cfg.Password.BcryptCost = v.GetInt("BCRYPT_COST")
+
+	v.SetDefault("EMPLOYEES_REQUIRE_IF_MATCH", false)
+	cfg.Employees.RequireIfMatch = v.GetBool("EMPLOYEES_REQUIRE_IF_MATCH")
+
+	return cfg, nil
 }
 
 func NewPostgresDB(cfg DatabaseConfiguration) (*sqlx.DB, error) {